@@ -0,0 +1,74 @@
+package authz
+
+import (
+	"context"
+	"task-management-system/models"
+)
+
+// Action is something a user does with a single task.
+type Action string
+
+const (
+	ActionView       Action = "view"
+	ActionUpdate     Action = "update"     // edit any field of the task
+	ActionTransition Action = "transition" // change only the status
+	ActionComment    Action = "comment"
+	ActionDelete     Action = "delete"
+)
+
+// Actor is the authenticated user as seen by the policy.
+type Actor struct {
+	UserID      int
+	Role        string
+	Permissions map[string]bool
+}
+
+// ActorFromContext reads the values JWTMiddleware and RequirePermission put
+// into the request context.
+func ActorFromContext(ctx context.Context) Actor {
+	userID, _ := ctx.Value("userID").(int)
+	role, _ := ctx.Value("role").(string)
+	permissions, _ := ctx.Value("permissions").(map[string]bool)
+	return Actor{UserID: userID, Role: role, Permissions: permissions}
+}
+
+func (a Actor) Has(permission string) bool {
+	return a.Permissions[permission]
+}
+
+// Can is the single place that decides what a user may do with a task:
+//   - creators manage their own tasks with the :own permissions,
+//   - assignees see the task, change its status and comment on it,
+//   - only the :any permissions reach tasks of other users.
+func Can(actor Actor, action Action, task models.Task) bool {
+	isCreator := task.UserID == actor.UserID
+	isAssignee := task.AssignedTo != 0 && task.AssignedTo == actor.UserID
+
+	switch action {
+	case ActionView:
+		return actor.Has(models.PermTaskReadAny) ||
+			(actor.Has(models.PermTaskRead) && (isCreator || isAssignee))
+	case ActionUpdate:
+		return actor.Has(models.PermTaskUpdateAny) ||
+			(actor.Has(models.PermTaskUpdateOwn) && isCreator)
+	case ActionTransition:
+		return Can(actor, ActionUpdate, task) ||
+			(actor.Has(models.PermTaskTransition) && isAssignee)
+	case ActionComment:
+		return actor.Has(models.PermTaskComment) && Can(actor, ActionView, task)
+	case ActionDelete:
+		return actor.Has(models.PermTaskDeleteAny) ||
+			(actor.Has(models.PermTaskDeleteOwn) && isCreator)
+	}
+	return false
+}
+
+// ViewScope is ActionView for lists of tasks: it returns 0 when the actor
+// may view every task, otherwise the user whose created or assigned tasks the
+// list is limited to.
+func ViewScope(actor Actor) int {
+	if actor.Has(models.PermTaskReadAny) {
+		return 0
+	}
+	return actor.UserID
+}
@@ -0,0 +1,21 @@
+// Package blob stores the contents of uploaded files. The database only keeps
+// their metadata and the key they are stored under.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob is stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+type Store interface {
+	// Put stores size bytes read from r under the key, replacing any blob
+	// stored under it before.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete does not fail when the blob does not exist.
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,632 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"task-management-system/models"
+	"task-management-system/workflow"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is loaded in this order, later sources overriding earlier ones:
+// defaults, YAML file (-config flag or CONFIG_FILE env), environment
+// variables, command line flags.
+type Config struct {
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	JWT           JWTConfig           `yaml:"jwt"`
+	Tasks         TasksConfig         `yaml:"tasks"`
+	Reminders     RemindersConfig     `yaml:"reminders"`
+	Attachments   AttachmentsConfig   `yaml:"attachments"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks"`
+	Events        EventsConfig        `yaml:"events"`
+	Stream        StreamConfig        `yaml:"stream"`
+	// Workflow defaults to workflow.Default() when the file defines no
+	// transitions.
+	Workflow workflow.Workflow `yaml:"workflow"`
+}
+
+type ServerConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+type DatabaseConfig struct {
+	Driver      string `yaml:"driver"` // mysql - sqlite
+	DSN         string `yaml:"dsn"`
+	AutoMigrate bool   `yaml:"auto_migrate"`
+}
+
+type JWTConfig struct {
+	Secret     string        `yaml:"secret"`
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+	// PruneInterval is how often expired entries are removed from the
+	// revocation list.
+	PruneInterval time.Duration `yaml:"prune_interval"`
+}
+
+type TasksConfig struct {
+	// TrashRetention is how long deleted tasks stay restorable before they
+	// are purged, checked every PurgeInterval.
+	TrashRetention time.Duration `yaml:"trash_retention"`
+	PurgeInterval  time.Duration `yaml:"purge_interval"`
+	// RecurrenceInterval is how often recurring tasks are checked for a due
+	// next occurrence.
+	RecurrenceInterval time.Duration `yaml:"recurrence_interval"`
+}
+
+type RemindersConfig struct {
+	// Interval is how often due dates are checked.
+	Interval time.Duration `yaml:"interval"`
+	// LeadHours are the default reminder times before the due date, users
+	// can choose their own.
+	LeadHours []int `yaml:"lead_hours"`
+	// GracePeriod is how long a task may stay overdue before its creator is
+	// notified.
+	GracePeriod time.Duration `yaml:"grace_period"`
+}
+
+type AttachmentsConfig struct {
+	Storage string `yaml:"storage"` // local - s3
+	// Dir is where the local storage keeps the files.
+	Dir string `yaml:"dir"`
+	// MaxSize is the largest accepted upload in bytes.
+	MaxSize int64 `yaml:"max_size"`
+	// AllowedTypes lists the accepted media types, detected from the file
+	// contents rather than taken from the client.
+	AllowedTypes []string `yaml:"allowed_types"`
+	S3           S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// PathStyle addresses the bucket as http://endpoint/bucket, needed by
+	// most local stand-ins such as MinIO.
+	PathStyle bool `yaml:"path_style"`
+}
+
+type NotificationsConfig struct {
+	// DefaultChannels are used for the notification types a user has not
+	// chosen channels for.
+	DefaultChannels []string `yaml:"default_channels"`
+	// BaseURL is the address of the web client, used for links in emails.
+	BaseURL string `yaml:"base_url"`
+	// TemplatesDir holds email templates overriding the built-in ones, one
+	// <type>.tmpl file per notification type.
+	TemplatesDir string     `yaml:"templates_dir"`
+	SMTP         SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig configures the email channel, which is disabled while Host is
+// empty.
+type SMTPConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	From     string        `yaml:"from"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+type WebhooksConfig struct {
+	// Interval is how often due deliveries are sent.
+	Interval time.Duration `yaml:"interval"`
+	// Timeout limits a single attempt.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts is the number of attempts before a delivery fails. The
+	// wait after a failed attempt starts at Backoff and doubles up to
+	// MaxBackoff.
+	MaxAttempts int           `yaml:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	// Retention is how long finished deliveries stay in the delivery log.
+	Retention time.Duration `yaml:"retention"`
+}
+
+type EventsConfig struct {
+	// DispatchInterval is how often the outbox is checked for events saved
+	// by other processes or left behind by failed deliveries. Events saved
+	// by this process are published right away.
+	DispatchInterval time.Duration `yaml:"dispatch_interval"`
+	// MaxAttempts is the number of publishes before an event a subscriber
+	// keeps failing on is given up on. The wait after a failed publish
+	// starts at Backoff and doubles up to MaxBackoff.
+	MaxAttempts int           `yaml:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	// Retention is how long published events stay in the outbox, e.g. for
+	// stream clients resuming after a restart.
+	Retention time.Duration `yaml:"retention"`
+}
+
+type StreamConfig struct {
+	// ReplayBuffer is the number of recent events kept for clients resuming
+	// with Last-Event-ID.
+	ReplayBuffer int `yaml:"replay_buffer"`
+	// Heartbeat is how often idle streams get a comment line, so proxies
+	// do not close them.
+	Heartbeat time.Duration `yaml:"heartbeat"`
+}
+
+const defaultSQLiteDSN = "file:task_management.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{Addr: ":8080"},
+		Database: DatabaseConfig{
+			Driver:      "mysql",
+			AutoMigrate: true,
+		},
+		JWT: JWTConfig{
+			AccessTTL:     15 * time.Minute,
+			RefreshTTL:    30 * 24 * time.Hour,
+			PruneInterval: time.Hour,
+		},
+		Tasks: TasksConfig{
+			TrashRetention:     30 * 24 * time.Hour,
+			PurgeInterval:      time.Hour,
+			RecurrenceInterval: time.Minute,
+		},
+		Reminders: RemindersConfig{
+			Interval:    5 * time.Minute,
+			LeadHours:   []int{24},
+			GracePeriod: 24 * time.Hour,
+		},
+		Attachments: AttachmentsConfig{
+			Storage: "local",
+			Dir:     "./data/attachments",
+			MaxSize: 10 << 20,
+			AllowedTypes: []string{
+				"image/png", "image/jpeg", "image/gif", "image/webp",
+				"application/pdf", "text/plain", "application/zip",
+			},
+			S3: S3Config{Region: "us-east-1"},
+		},
+		Notifications: NotificationsConfig{
+			DefaultChannels: []string{models.ChannelInApp},
+			SMTP:            SMTPConfig{Port: 587, Timeout: 10 * time.Second},
+		},
+		Webhooks: WebhooksConfig{
+			Interval:    5 * time.Second,
+			Timeout:     10 * time.Second,
+			MaxAttempts: 8,
+			Backoff:     30 * time.Second,
+			MaxBackoff:  time.Hour,
+			Retention:   30 * 24 * time.Hour,
+		},
+		Events: EventsConfig{
+			DispatchInterval: time.Second,
+			MaxAttempts:      10,
+			Backoff:          5 * time.Second,
+			MaxBackoff:       time.Hour,
+			Retention:        7 * 24 * time.Hour,
+		},
+		Stream: StreamConfig{
+			ReplayBuffer: 1000,
+			Heartbeat:    25 * time.Second,
+		},
+	}
+}
+
+// Load builds the configuration from args (without the program name) and
+// returns the arguments left after the flags, e.g. a subcommand.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("task-management-system", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	addr := fs.String("addr", "", "listen address, e.g. :8080")
+	driver := fs.String("db-driver", "", "database driver: mysql or sqlite")
+	dsn := fs.String("db-dsn", "", "database data source name")
+	autoMigrate := fs.Bool("auto-migrate", true, "apply pending migrations at boot")
+	accessTTL := fs.Duration("jwt-access-ttl", 0, "lifetime of access tokens")
+	refreshTTL := fs.Duration("jwt-refresh-ttl", 0, "lifetime of refresh tokens")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.Server.Addr = *addr
+		case "db-driver":
+			cfg.Database.Driver = *driver
+		case "db-dsn":
+			cfg.Database.DSN = *dsn
+		case "auto-migrate":
+			cfg.Database.AutoMigrate = *autoMigrate
+		case "jwt-access-ttl":
+			cfg.JWT.AccessTTL = *accessTTL
+		case "jwt-refresh-ttl":
+			cfg.JWT.RefreshTTL = *refreshTTL
+		}
+	})
+
+	if len(cfg.Workflow.Transitions) == 0 {
+		cfg.Workflow = workflow.Default()
+	}
+
+	if cfg.Database.Driver == "sqlite" && cfg.Database.DSN == "" {
+		cfg.Database.DSN = defaultSQLiteDSN
+	}
+
+	return &cfg, fs.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	if v := os.Getenv("SERVER_ADDR"); v != "" {
+		c.Server.Addr = v
+	}
+	if v := os.Getenv("DB_DRIVER"); v != "" {
+		c.Database.Driver = v
+	}
+	if v := os.Getenv("DB_DSN"); v != "" {
+		c.Database.DSN = v
+	}
+	if v := os.Getenv("DB_AUTO_MIGRATE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("DB_AUTO_MIGRATE: %w", err)
+		}
+		c.Database.AutoMigrate = b
+	}
+	if v := os.Getenv("JWT_SECRET_KEY"); v != "" {
+		c.JWT.Secret = v
+	}
+	if err := envDuration("JWT_ACCESS_TTL", &c.JWT.AccessTTL); err != nil {
+		return err
+	}
+	if err := envDuration("JWT_REFRESH_TTL", &c.JWT.RefreshTTL); err != nil {
+		return err
+	}
+	if err := envDuration("JWT_PRUNE_INTERVAL", &c.JWT.PruneInterval); err != nil {
+		return err
+	}
+	if err := envDuration("TASK_TRASH_RETENTION", &c.Tasks.TrashRetention); err != nil {
+		return err
+	}
+	if err := envDuration("TASK_PURGE_INTERVAL", &c.Tasks.PurgeInterval); err != nil {
+		return err
+	}
+	if err := envDuration("TASK_RECURRENCE_INTERVAL", &c.Tasks.RecurrenceInterval); err != nil {
+		return err
+	}
+	if err := envDuration("REMINDER_INTERVAL", &c.Reminders.Interval); err != nil {
+		return err
+	}
+	if err := envDuration("REMINDER_GRACE_PERIOD", &c.Reminders.GracePeriod); err != nil {
+		return err
+	}
+	if v := os.Getenv("REMINDER_LEAD_HOURS"); v != "" {
+		c.Reminders.LeadHours = nil
+		for _, h := range strings.Split(v, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(h))
+			if err != nil {
+				return fmt.Errorf("REMINDER_LEAD_HOURS: %w", err)
+			}
+			c.Reminders.LeadHours = append(c.Reminders.LeadHours, n)
+		}
+	}
+	if v := os.Getenv("ATTACHMENT_STORAGE"); v != "" {
+		c.Attachments.Storage = v
+	}
+	if v := os.Getenv("ATTACHMENT_DIR"); v != "" {
+		c.Attachments.Dir = v
+	}
+	if v := os.Getenv("ATTACHMENT_MAX_SIZE"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ATTACHMENT_MAX_SIZE: %w", err)
+		}
+		c.Attachments.MaxSize = n
+	}
+	if v := os.Getenv("S3_ENDPOINT"); v != "" {
+		c.Attachments.S3.Endpoint = v
+	}
+	if v := os.Getenv("S3_REGION"); v != "" {
+		c.Attachments.S3.Region = v
+	}
+	if v := os.Getenv("S3_BUCKET"); v != "" {
+		c.Attachments.S3.Bucket = v
+	}
+	if v := os.Getenv("S3_ACCESS_KEY_ID"); v != "" {
+		c.Attachments.S3.AccessKeyID = v
+	}
+	if v := os.Getenv("S3_SECRET_ACCESS_KEY"); v != "" {
+		c.Attachments.S3.SecretAccessKey = v
+	}
+	if v := os.Getenv("S3_PATH_STYLE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("S3_PATH_STYLE: %w", err)
+		}
+		c.Attachments.S3.PathStyle = b
+	}
+	if v := os.Getenv("NOTIFICATION_BASE_URL"); v != "" {
+		c.Notifications.BaseURL = v
+	}
+	if v := os.Getenv("SMTP_HOST"); v != "" {
+		c.Notifications.SMTP.Host = v
+	}
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("SMTP_PORT: %w", err)
+		}
+		c.Notifications.SMTP.Port = n
+	}
+	if v := os.Getenv("SMTP_USERNAME"); v != "" {
+		c.Notifications.SMTP.Username = v
+	}
+	if v := os.Getenv("SMTP_PASSWORD"); v != "" {
+		c.Notifications.SMTP.Password = v
+	}
+	if v := os.Getenv("SMTP_FROM"); v != "" {
+		c.Notifications.SMTP.From = v
+	}
+	if err := envDuration("WEBHOOK_INTERVAL", &c.Webhooks.Interval); err != nil {
+		return err
+	}
+	if err := envDuration("WEBHOOK_TIMEOUT", &c.Webhooks.Timeout); err != nil {
+		return err
+	}
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS: %w", err)
+		}
+		c.Webhooks.MaxAttempts = n
+	}
+	if err := envDuration("WEBHOOK_BACKOFF", &c.Webhooks.Backoff); err != nil {
+		return err
+	}
+	if err := envDuration("WEBHOOK_MAX_BACKOFF", &c.Webhooks.MaxBackoff); err != nil {
+		return err
+	}
+	if err := envDuration("WEBHOOK_RETENTION", &c.Webhooks.Retention); err != nil {
+		return err
+	}
+	if err := envDuration("EVENT_DISPATCH_INTERVAL", &c.Events.DispatchInterval); err != nil {
+		return err
+	}
+	if v := os.Getenv("EVENT_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("EVENT_MAX_ATTEMPTS: %w", err)
+		}
+		c.Events.MaxAttempts = n
+	}
+	if err := envDuration("EVENT_BACKOFF", &c.Events.Backoff); err != nil {
+		return err
+	}
+	if err := envDuration("EVENT_MAX_BACKOFF", &c.Events.MaxBackoff); err != nil {
+		return err
+	}
+	if err := envDuration("EVENT_RETENTION", &c.Events.Retention); err != nil {
+		return err
+	}
+	if v := os.Getenv("STREAM_REPLAY_BUFFER"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("STREAM_REPLAY_BUFFER: %w", err)
+		}
+		c.Stream.ReplayBuffer = n
+	}
+	if err := envDuration("STREAM_HEARTBEAT", &c.Stream.Heartbeat); err != nil {
+		return err
+	}
+	return nil
+}
+
+func envDuration(name string, dst *time.Duration) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*dst = d
+	return nil
+}
+
+func (c *Config) Validate() error {
+	return errors.Join(c.Server.Validate(), c.Database.Validate(), c.JWT.Validate(), c.Tasks.Validate(), c.Reminders.Validate(), c.Attachments.Validate(), c.Notifications.Validate(), c.Webhooks.Validate(), c.Events.Validate(), c.Stream.Validate(), c.Workflow.Validate())
+}
+
+func (c ServerConfig) Validate() error {
+	if c.Addr == "" {
+		return errors.New("server.addr is required")
+	}
+	return nil
+}
+
+func (c DatabaseConfig) Validate() error {
+	var errs []error
+	if c.Driver != "mysql" && c.Driver != "sqlite" {
+		errs = append(errs, fmt.Errorf("database.driver must be mysql or sqlite, got %q", c.Driver))
+	}
+	if c.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required (DB_DSN)"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c JWTConfig) Validate() error {
+	var errs []error
+	if c.Secret == "" {
+		errs = append(errs, errors.New("jwt.secret is required (JWT_SECRET_KEY)"))
+	}
+	if c.AccessTTL <= 0 {
+		errs = append(errs, errors.New("jwt.access_ttl must be positive"))
+	}
+	if c.RefreshTTL <= c.AccessTTL {
+		errs = append(errs, errors.New("jwt.refresh_ttl must be longer than jwt.access_ttl"))
+	}
+	if c.PruneInterval <= 0 {
+		errs = append(errs, errors.New("jwt.prune_interval must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c TasksConfig) Validate() error {
+	var errs []error
+	if c.TrashRetention <= 0 {
+		errs = append(errs, errors.New("tasks.trash_retention must be positive"))
+	}
+	if c.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("tasks.purge_interval must be positive"))
+	}
+	if c.RecurrenceInterval <= 0 {
+		errs = append(errs, errors.New("tasks.recurrence_interval must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c RemindersConfig) Validate() error {
+	var errs []error
+	if c.Interval <= 0 {
+		errs = append(errs, errors.New("reminders.interval must be positive"))
+	}
+	for _, h := range c.LeadHours {
+		if h < 1 || h > models.MaxReminderLeadHours {
+			errs = append(errs, fmt.Errorf("reminders.lead_hours must be between 1 and %d, got %d", models.MaxReminderLeadHours, h))
+		}
+	}
+	if c.GracePeriod < 0 {
+		errs = append(errs, errors.New("reminders.grace_period must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c AttachmentsConfig) Validate() error {
+	var errs []error
+	switch c.Storage {
+	case "local":
+		if c.Dir == "" {
+			errs = append(errs, errors.New("attachments.dir is required for local storage"))
+		}
+	case "s3":
+		if c.S3.Endpoint == "" || c.S3.Bucket == "" || c.S3.Region == "" {
+			errs = append(errs, errors.New("attachments.s3.endpoint, bucket and region are required for s3 storage"))
+		}
+		if c.S3.AccessKeyID == "" || c.S3.SecretAccessKey == "" {
+			errs = append(errs, errors.New("attachments.s3 credentials are required (S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("attachments.storage must be local or s3, got %q", c.Storage))
+	}
+	if c.MaxSize <= 0 {
+		errs = append(errs, errors.New("attachments.max_size must be positive"))
+	}
+	if len(c.AllowedTypes) == 0 {
+		errs = append(errs, errors.New("attachments.allowed_types must not be empty"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c NotificationsConfig) Validate() error {
+	var errs []error
+	for _, ch := range c.DefaultChannels {
+		if !models.IsNotificationChannel(ch) {
+			errs = append(errs, fmt.Errorf("notifications.default_channels: unknown channel %q", ch))
+		}
+	}
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			errs = append(errs, fmt.Errorf("notifications.smtp.port must be between 1 and 65535, got %d", c.SMTP.Port))
+		}
+		if c.SMTP.From == "" {
+			errs = append(errs, errors.New("notifications.smtp.from is required when smtp.host is set (SMTP_FROM)"))
+		}
+		if c.SMTP.Timeout <= 0 {
+			errs = append(errs, errors.New("notifications.smtp.timeout must be positive"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c WebhooksConfig) Validate() error {
+	var errs []error
+	if c.Interval <= 0 {
+		errs = append(errs, errors.New("webhooks.interval must be positive"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout must be positive"))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhooks.max_attempts must be at least 1"))
+	}
+	if c.Backoff <= 0 || c.MaxBackoff < c.Backoff {
+		errs = append(errs, errors.New("webhooks.backoff must be positive and at most webhooks.max_backoff"))
+	}
+	if c.Retention <= 0 {
+		errs = append(errs, errors.New("webhooks.retention must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c EventsConfig) Validate() error {
+	var errs []error
+	if c.DispatchInterval <= 0 {
+		errs = append(errs, errors.New("events.dispatch_interval must be positive"))
+	}
+	if c.MaxAttempts < 1 {
+		errs = append(errs, errors.New("events.max_attempts must be at least 1"))
+	}
+	if c.Backoff <= 0 || c.MaxBackoff < c.Backoff {
+		errs = append(errs, errors.New("events.backoff must be positive and at most events.max_backoff"))
+	}
+	if c.Retention <= 0 {
+		errs = append(errs, errors.New("events.retention must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c StreamConfig) Validate() error {
+	var errs []error
+	if c.ReplayBuffer < 1 {
+		errs = append(errs, errors.New("stream.replay_buffer must be at least 1"))
+	}
+	if c.Heartbeat <= 0 {
+		errs = append(errs, errors.New("stream.heartbeat must be positive"))
+	}
+	return errors.Join(errs...)
+}
@@ -7,18 +7,24 @@ import (
 
 var db *sql.DB
 
-
-func InitDB(dataSourceName string) *sql.DB {
+// InitDB opens a connection pool for the given driver ("mysql" or "sqlite").
+// The schema is managed by the migrations package.
+func InitDB(driver, dataSourceName string) *sql.DB {
 	var err error
-	db, err = sql.Open("mysql", dataSourceName)
+	db, err = sql.Open(driver, dataSourceName)
 	if err != nil {
 		log.Panic(err)
 	}
 
+	if driver == "sqlite" {
+		// SQLite allows a single writer and every connection to ":memory:"
+		// opens a new empty database, so keep the pool to one connection.
+		db.SetMaxOpenConns(1)
+	}
+
 	if err = db.Ping(); err != nil {
 		log.Panic(err)
 	}
 
 	return db
-	
-}
\ No newline at end of file
+}
@@ -0,0 +1,62 @@
+// Package events carries the events of the handlers to the parts of the
+// system reacting to them, such as webhooks. Events are written to the outbox
+// with the change they describe and published by the Dispatcher.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"task-management-system/models"
+	"time"
+)
+
+// Subscriber is called for every published event. It runs on the goroutine
+// of the publisher and must not block. An event may be delivered more than
+// once, e.g. when another subscriber failed on it, so subscribers use the
+// event ID to tell repeats apart when that matters. A returned error makes
+// the Dispatcher publish the event again later.
+type Subscriber func(ev models.Event) error
+
+// Bus delivers events to its subscribers in process. Events published from
+// one goroutine, as the Dispatcher does, arrive in the order they were
+// published.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers []Subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(fn Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish hands the event to every subscriber, even when some of them fail,
+// and returns their errors. The subscribers are called without holding the
+// lock, so a slow one does not hold up Subscribe.
+func (b *Bus) Publish(ev models.Event) error {
+	b.mu.Lock()
+	subscribers := append([]Subscriber(nil), b.subscribers...)
+	b.mu.Unlock()
+	var errs []error
+	for _, fn := range subscribers {
+		if err := fn(ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// New builds an event of the given type with data encoded as JSON.
+func New(eventType string, actorID int, data interface{}) (models.Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return models.Event{}, err
+	}
+	return models.Event{Type: eventType, ActorID: actorID, CreatedAt: time.Now().UTC(), Data: raw}, nil
+}
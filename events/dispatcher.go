@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"log"
+	"task-management-system/store"
+	"time"
+)
+
+const (
+	// dispatchBatch is the number of events read from the outbox at a time.
+	dispatchBatch = 100
+	// maxErrorLength bounds the error kept with a failed event.
+	maxErrorLength = 1000
+)
+
+// Dispatcher publishes the events of the outbox to the Bus and marks them
+// published. Every event gets the next sequence number before it is first
+// published, so subscribers see the numbers ascend even when the outbox IDs
+// were committed out of order; a retried event keeps its number. Delivery is
+// at least once: an event is published again when a subscriber fails on it
+// or when the process stops before it was marked. An event a subscriber
+// fails on is retried after Backoff, doubled after every further failure up
+// to MaxBackoff, and kept as a failed event after MaxAttempts publishes; the
+// events after it are published meanwhile. Only one instance should run the
+// dispatcher, events are not claimed.
+type Dispatcher struct {
+	Outbox      store.OutboxStore
+	Bus         *Bus
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	wake        chan struct{}
+}
+
+func NewDispatcher(outbox store.OutboxStore, bus *Bus, interval time.Duration) *Dispatcher {
+	return &Dispatcher{Outbox: outbox, Bus: bus, Interval: interval, wake: make(chan struct{}, 1)}
+}
+
+// Wake makes Run look at the outbox now instead of at the next interval. It
+// is called after a change was saved and never blocks.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Dispatch(); err != nil {
+			log.Println("Error dispatching events: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Dispatch publishes the unpublished events that are due.
+func (d *Dispatcher) Dispatch() error {
+	now := time.Now()
+	for {
+		batch, err := d.Outbox.ListUnpublishedEvents(now, dispatchBatch)
+		if err != nil {
+			return err
+		}
+		if err := d.Outbox.SequenceEvents(batch); err != nil {
+			return err
+		}
+		var published []int64
+		for _, ev := range batch {
+			failed := d.Bus.Publish(ev)
+			if failed == nil {
+				published = append(published, ev.ID)
+				continue
+			}
+			var next *time.Time
+			if attempts := ev.Attempts + 1; attempts < d.MaxAttempts {
+				at := now.Add(Backoff(attempts, d.Backoff, d.MaxBackoff))
+				next = &at
+				log.Printf("Error publishing event %d, retrying at %s: %v", ev.ID, at.Format(time.RFC3339), failed)
+			} else {
+				log.Printf("Error publishing event %d, giving up after %d attempts: %v", ev.ID, attempts, failed)
+			}
+			msg := failed.Error()
+			if len(msg) > maxErrorLength {
+				msg = msg[:maxErrorLength]
+			}
+			if err := d.Outbox.MarkEventFailed(ev.ID, msg, next, now); err != nil {
+				return err
+			}
+		}
+		if err := d.Outbox.MarkEventsPublished(published, now); err != nil {
+			return err
+		}
+		if len(batch) < dispatchBatch {
+			return nil
+		}
+	}
+}
+
+// Backoff returns the wait before the next attempt after the given number of
+// failed attempts: base, 2*base, 4*base and so on, at most max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"task-management-system/models"
+	"task-management-system/store"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts, time.Second, 5*time.Second); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// retryOutbox keeps the events in memory and records the failures. Events
+// waiting for a retry are due at the next Dispatch, whatever the wait.
+type retryOutbox struct {
+	store.OutboxStore
+	events    []models.Event
+	published map[int64]bool
+	failed    map[int64]bool
+	// waits are the waits before the retries, zero for a failed event.
+	waits   map[int64][]time.Duration
+	lastSeq int64
+}
+
+func newRetryOutbox(n int) *retryOutbox {
+	o := &retryOutbox{published: map[int64]bool{}, failed: map[int64]bool{}, waits: map[int64][]time.Duration{}}
+	for id := int64(1); id <= int64(n); id++ {
+		o.events = append(o.events, models.Event{ID: id, Type: models.EventTaskCreated})
+	}
+	return o
+}
+
+func (o *retryOutbox) ListUnpublishedEvents(now time.Time, limit int) ([]models.Event, error) {
+	var evs []models.Event
+	for _, ev := range o.events {
+		if !o.published[ev.ID] && !o.failed[ev.ID] && len(evs) < limit {
+			evs = append(evs, ev)
+		}
+	}
+	return evs, nil
+}
+
+func (o *retryOutbox) SequenceEvents(evs []models.Event) error {
+	for i := range evs {
+		if evs[i].Seq == 0 {
+			o.lastSeq++
+			evs[i].Seq = o.lastSeq
+			o.events[evs[i].ID-1].Seq = o.lastSeq
+		}
+	}
+	return nil
+}
+
+func (o *retryOutbox) MarkEventsPublished(ids []int64, at time.Time) error {
+	for _, id := range ids {
+		o.published[id] = true
+	}
+	return nil
+}
+
+func (o *retryOutbox) MarkEventFailed(id int64, lastError string, next *time.Time, at time.Time) error {
+	o.events[id-1].Attempts++
+	if next == nil {
+		o.failed[id] = true
+		o.waits[id] = append(o.waits[id], 0)
+		return nil
+	}
+	o.waits[id] = append(o.waits[id], next.Sub(at))
+	return nil
+}
+
+func TestDispatchRetries(t *testing.T) {
+	tests := []struct {
+		name string
+		// failures is the number of times the subscriber fails on event 2.
+		failures      int
+		wantAttempts  int
+		wantWaits     []time.Duration
+		wantPublished bool
+		wantFailed    bool
+	}{
+		{"published at once", 0, 1, nil, true, false},
+		{"retried once", 1, 2, []time.Duration{time.Second}, true, false},
+		{"backoff doubles up to the maximum", 3, 4, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, true, false},
+		{"failed after MaxAttempts", 10, 4, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 0}, false, true},
+	}
+	for _, tt := range tests {
+		outbox := newRetryOutbox(3)
+		bus := NewBus()
+		var got []models.Event
+		failures := tt.failures
+		bus.Subscribe(func(ev models.Event) error {
+			got = append(got, ev)
+			if ev.ID == 2 && failures > 0 {
+				failures--
+				return errors.New("unavailable")
+			}
+			return nil
+		})
+		d := NewDispatcher(outbox, bus, time.Minute)
+		d.MaxAttempts, d.Backoff, d.MaxBackoff = 4, time.Second, 3*time.Second
+		for i := 0; i < 6; i++ {
+			if err := d.Dispatch(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if waits := outbox.waits[2]; !reflect.DeepEqual(waits, tt.wantWaits) {
+			t.Errorf("%s: waits = %v, want %v", tt.name, waits, tt.wantWaits)
+		}
+		if outbox.published[2] != tt.wantPublished || outbox.failed[2] != tt.wantFailed {
+			t.Errorf("%s: published %v, failed %v, want %v, %v", tt.name, outbox.published[2], outbox.failed[2], tt.wantPublished, tt.wantFailed)
+		}
+		// the other events are published once, in order, while event 2 waits
+		var ids []int64
+		for _, ev := range got {
+			if ev.ID != 2 {
+				ids = append(ids, ev.ID)
+				continue
+			}
+			// a retried event keeps its sequence number
+			if ev.Seq != 2 {
+				t.Errorf("%s: event 2 was published with the sequence number %d", tt.name, ev.Seq)
+			}
+		}
+		if !reflect.DeepEqual(ids, []int64{1, 3}) || !outbox.published[1] || !outbox.published[3] {
+			t.Errorf("%s: published the other events %v, want [1 3]", tt.name, ids)
+		}
+		if attempts := len(got) - len(ids); attempts != tt.wantAttempts {
+			t.Errorf("%s: event 2 was published %d times, want %d", tt.name, attempts, tt.wantAttempts)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"sync"
+	"task-management-system/models"
+)
+
+// clientBuffer is the number of events a client may fall behind before it is
+// dropped. Dropped clients reconnect and catch up from the replay buffer.
+const clientBuffer = 64
+
+// Hub fans events out to streaming clients and keeps the latest ones so
+// reconnecting clients can resume where they left off.
+type Hub struct {
+	mu      sync.Mutex
+	size    int
+	recent  []models.Event
+	last    int64 // sequence number of the latest event
+	evicted int64 // events up to this sequence number are not kept
+	clients map[*Client]struct{}
+}
+
+// Client receives the events published after it subscribed. C is closed
+// when the client falls too far behind.
+type Client struct {
+	C chan models.Event
+}
+
+// NewHub keeps up to size events for replay.
+func NewHub(size int) *Hub {
+	return &Hub{size: size, clients: make(map[*Client]struct{})}
+}
+
+// Publish is subscribed to the Bus. New events arrive in sequence order, so
+// one at or below the latest is a repeat and is skipped.
+func (h *Hub) Publish(ev models.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ev.Seq <= h.last {
+		return nil
+	}
+	if ev.Seq > h.last+1 {
+		// aradaki olaylar, örneğin yeniden başlatmadan önce yayımlananlar, bilinmiyor
+		h.recent = nil
+		h.evicted = ev.Seq - 1
+	}
+	h.last = ev.Seq
+	h.recent = append(h.recent, ev)
+	if len(h.recent) > h.size {
+		h.evicted = h.recent[0].Seq
+		h.recent = h.recent[1:]
+	}
+	for c := range h.clients {
+		select {
+		case c.C <- ev:
+		default:
+			// yavaş istemci, yeniden bağlanıp kaldığı yerden devam eder
+			delete(h.clients, c)
+			close(c.C)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a client. When lastSeq is not zero it also returns the
+// events published after lastSeq; complete is false when some of them are no
+// longer kept, e.g. after a restart, and have to be read from the outbox.
+func (h *Hub) Subscribe(lastSeq int64) (c *Client, missed []models.Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c = &Client{C: make(chan models.Event, clientBuffer)}
+	h.clients[c] = struct{}{}
+	if lastSeq == 0 {
+		return c, nil, true
+	}
+	for _, ev := range h.recent {
+		if ev.Seq > lastSeq {
+			missed = append(missed, ev)
+		}
+	}
+	return c, missed, lastSeq >= h.evicted && lastSeq <= h.last
+}
+
+func (h *Hub) Unsubscribe(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.C)
+	}
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"reflect"
+	"task-management-system/models"
+	"testing"
+)
+
+func publishSeqs(t *testing.T, h *Hub, seqs ...int64) {
+	t.Helper()
+	for _, seq := range seqs {
+		if err := h.Publish(models.Event{ID: seq, Seq: seq}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func seqs(evs []models.Event) []int64 {
+	var seqs []int64
+	for _, ev := range evs {
+		seqs = append(seqs, ev.Seq)
+	}
+	return seqs
+}
+
+func TestHubSubscribeReplay(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		published    []int64
+		lastSeq      int64
+		wantMissed   []int64
+		wantComplete bool
+	}{
+		{"new client", 3, []int64{1, 2, 3}, 0, nil, true},
+		{"up to date", 3, []int64{1, 2, 3}, 3, nil, true},
+		{"missed some", 3, []int64{1, 2, 3}, 1, []int64{2, 3}, true},
+		{"repeats are skipped", 3, []int64{1, 2, 2, 1, 3}, 1, []int64{2, 3}, true},
+		{"missed all that are kept", 3, []int64{1, 2, 3, 4, 5}, 2, []int64{3, 4, 5}, true},
+		{"missed more than are kept", 3, []int64{1, 2, 3, 4, 5}, 1, []int64{3, 4, 5}, false},
+		// e.g. the events before a restart
+		{"gap before the kept events", 3, []int64{7, 8}, 5, []int64{7, 8}, false},
+		{"nothing published since a restart", 3, nil, 5, nil, false},
+		{"ahead of the hub", 3, []int64{1, 2}, 4, nil, false},
+	}
+	for _, tt := range tests {
+		h := NewHub(tt.size)
+		publishSeqs(t, h, tt.published...)
+		c, missed, complete := h.Subscribe(tt.lastSeq)
+		if got := seqs(missed); !reflect.DeepEqual(got, tt.wantMissed) || complete != tt.wantComplete {
+			t.Errorf("%s: Subscribe(%d) = %v, %v, want %v, %v", tt.name, tt.lastSeq, got, complete, tt.wantMissed, tt.wantComplete)
+		}
+		h.Unsubscribe(c)
+	}
+}
+
+func TestHubDropsSlowClients(t *testing.T) {
+	h := NewHub(1)
+	slow, _, _ := h.Subscribe(0)
+	fast, _, _ := h.Subscribe(0)
+	for seq := int64(1); seq <= clientBuffer+1; seq++ {
+		publishSeqs(t, h, seq)
+		<-fast.C
+	}
+
+	var got []int64
+	for ev := range slow.C {
+		got = append(got, ev.Seq)
+	}
+	if len(got) != clientBuffer || got[len(got)-1] != clientBuffer {
+		t.Errorf("the slow client got %d events up to %v, want the first %d before it was dropped", len(got), got[len(got)-1:], clientBuffer)
+	}
+	// dropping the client closed it, unsubscribing it again is a no-op
+	h.Unsubscribe(slow)
+	h.Unsubscribe(fast)
+	if _, ok := <-fast.C; ok {
+		t.Error("the channel of an unsubscribed client is open")
+	}
+}
@@ -1,9 +1,67 @@
 package handlers
 
 import (
-	"database/sql"
+	"net/http"
+	"task-management-system/blob"
+	"task-management-system/events"
+	"task-management-system/notify"
+	"task-management-system/store"
+	"task-management-system/workflow"
+	"time"
 )
 
 type AppHandler struct {
-	DB *sql.DB
+	Tasks         store.TaskStore
+	History       store.TaskHistoryStore
+	Dependencies  store.TaskDependencyStore
+	Labels        store.LabelStore
+	Comments      store.CommentStore
+	Notifications store.NotificationStore
+	Attachments   store.AttachmentStore
+	Recurrences   store.RecurrenceStore
+	Reminders     store.ReminderStore
+	Users         store.UserStore
+	Friendships   store.FriendshipStore
+	RefreshTokens store.RefreshTokenStore
+	Revocations   store.RevocationStore
+	Roles         store.RoleStore
+	Webhooks      store.WebhookStore
+	Outbox        store.OutboxStore
+
+	// Notifier delivers notifications to the inbox and the other channels
+	// users chose.
+	Notifier *notify.Service
+	// Events publishes the task and friendship events saved to Outbox, see
+	// event.go.
+	Events *events.Dispatcher
+	// Stream keeps the events for the /events stream, which writes a
+	// comment every StreamHeartbeat to keep idle connections open.
+	Stream          *events.Hub
+	StreamHeartbeat time.Duration
+
+	JWTKey          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	Workflow workflow.Workflow
+
+	// ReminderLeadHours are the reminder times of users without their own
+	// preferences. EscalationGrace is how long a task stays overdue before
+	// its creator is notified.
+	ReminderLeadHours []int
+	EscalationGrace   time.Duration
+
+	Blobs blob.Store
+	// MaxAttachmentSize is in bytes. AllowedAttachmentTypes are media types
+	// without parameters.
+	MaxAttachmentSize      int64
+	AllowedAttachmentTypes []string
+
+	// WebhookClient sends webhook deliveries. A failed delivery is retried
+	// after WebhookBackoff, doubled after every further failure up to
+	// WebhookMaxBackoff, until WebhookMaxAttempts attempts were made.
+	WebhookClient      *http.Client
+	WebhookMaxAttempts int
+	WebhookBackoff     time.Duration
+	WebhookMaxBackoff  time.Duration
 }
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"task-management-system/authz"
+	"task-management-system/blob"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead is the room left for the multipart headers and
+// boundaries on top of MaxAttachmentSize.
+const multipartOverhead = 1 << 20
+
+// cleanFilename keeps the base name of the uploaded file without control
+// characters, it ends up in the Content-Disposition header of downloads.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if len(name) > 255 {
+		ext := filepath.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(ext)], "") + ext
+	}
+	return name
+}
+
+func (db *AppHandler) allowedAttachmentType(mediaType string) bool {
+	for _, t := range db.AllowedAttachmentTypes {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskAttachment loads the attachment named by the {attachment_id} route
+// variable. Attachments of other tasks are reported as not found.
+func (db *AppHandler) taskAttachment(r *http.Request, task models.Task) (models.Attachment, int, error) {
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["attachment_id"])
+	if err != nil {
+		return models.Attachment{}, http.StatusBadRequest, errors.New("Invalid attachment ID")
+	}
+	attachment, err := db.Attachments.GetAttachment(attachmentID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && attachment.TaskID != task.ID) {
+		return attachment, http.StatusNotFound, errors.New("Attachment not found")
+	}
+	if err != nil {
+		return attachment, http.StatusInternalServerError, err
+	}
+	return attachment, 0, nil
+}
+
+// GetAttachments godoc
+// @Summary List the attachments of a task
+// @Description Get the metadata of the files attached to a task, oldest first
+// @Tags attachments
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} models.Attachment
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/attachments [get]
+func (db *AppHandler) GetAttachments() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		attachments, err := db.Attachments.ListAttachments(task.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if attachments == nil {
+			attachments = []models.Attachment{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(attachments)
+	})
+}
+
+// CreateAttachment godoc
+// @Summary Attach a file to a task
+// @Description Upload a file as the "file" field of a multipart form. The type is detected from the contents and must be one of the allowed types, the size is limited by attachments.max_size.
+// @Tags attachments
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param file formData file true "File"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 413 {object} string
+// @Failure 415 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/attachments [post]
+func (db *AppHandler) CreateAttachment() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		tooLarge := fmt.Sprintf("File is larger than %d bytes", db.MaxAttachmentSize)
+
+		r.Body = http.MaxBytesReader(w, r.Body, db.MaxAttachmentSize+multipartOverhead)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, tooLarge, http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		if header.Size > db.MaxAttachmentSize {
+			http.Error(w, tooLarge, http.StatusRequestEntityTooLarge)
+			return
+		}
+		if header.Size == 0 {
+			http.Error(w, "file is empty", http.StatusBadRequest)
+			return
+		}
+
+		// istemcinin gönderdiği Content-Type'a güvenmeden içerikten tespit et
+		sniff := make([]byte, 512)
+		n, err := io.ReadFull(file, sniff)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		contentType := http.DetectContentType(sniff[:n])
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !db.allowedAttachmentType(mediaType) {
+			http.Error(w, fmt.Sprintf("File type %s is not allowed", mediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		random, err := randomToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		attachment := models.Attachment{
+			TaskID:      task.ID,
+			UploaderID:  r.Context().Value("userID").(int),
+			Filename:    cleanFilename(header.Filename),
+			ContentType: contentType,
+			Size:        header.Size,
+			StorageKey:  fmt.Sprintf("tasks/%d/%s", task.ID, random),
+			CreatedAt:   time.Now(),
+		}
+		if err := db.Blobs.Put(r.Context(), attachment.StorageKey, file, attachment.Size, contentType); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := db.Attachments.CreateAttachment(&attachment); err != nil {
+			if err := db.Blobs.Delete(r.Context(), attachment.StorageKey); err != nil {
+				log.Println("Error deleting blob: ", err)
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(attachment)
+	})
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Get the contents of a file attached to a task
+// @Tags attachments
+// @Produce  octet-stream
+// @Param task_id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/attachments/{attachment_id} [get]
+func (db *AppHandler) DownloadAttachment() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		attachment, code, err := db.taskAttachment(r, task)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		body, err := db.Blobs.Get(r.Context(), attachment.StorageKey)
+		if errors.Is(err, blob.ErrNotFound) {
+			http.Error(w, "Attachment contents not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer body.Close()
+
+		w.Header().Set("Content-Type", attachment.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, body); err != nil {
+			log.Println("Error sending attachment: ", err)
+		}
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Delete a file attached to a task. Uploaders can delete their own files, users who can edit the task any file on it.
+// @Tags attachments
+// @Param task_id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/attachments/{attachment_id} [delete]
+func (db *AppHandler) DeleteAttachment() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		attachment, code, err := db.taskAttachment(r, task)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		actor := authz.ActorFromContext(r.Context())
+		if attachment.UploaderID != actor.UserID && !authz.Can(actor, authz.ActionUpdate, task) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if err := db.Attachments.DeleteAttachment(attachment.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// satır silindi, blob silinemezse yalnızca kaydet
+		if err := db.Blobs.Delete(r.Context(), attachment.StorageKey); err != nil {
+			log.Println("Error deleting blob: ", err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -2,27 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"os"
 	"task-management-system/models"
-	"time"
+	"task-management-system/store"
 
-	"github.com/dgrijalva/jwt-go"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET_KEY"))
-
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with username, password, role, and email
+// @Description Register a new user with username, password and email. New users get the user role, admins change it with PUT /users/{user_id}/role.
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param user body models.User true "User info"
 // @Success 201 {object} models.User
 // @Failure 400 {object} string
+// @Failure 409 {object} string
 // @Failure 500 {object} string
 // @Router /register [post]
 func (db *AppHandler) Register() http.Handler {
@@ -36,6 +34,9 @@ func (db *AppHandler) Register() http.Handler {
 		}
 		log.Println("User data decoded: ", user)
 
+		// rolü istemci seçemez, yalnızca user:admin yetkisi olanlar değiştirir
+		user.Role = defaultRole
+
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -45,7 +46,11 @@ func (db *AppHandler) Register() http.Handler {
 		user.Password = string(hashedPassword)
 		log.Println("Password hashed:", user.Password)
 
-		_, err = db.DB.Exec("INSERT INTO users (username, password, role, email) VALUES (?, ?, ?, ?)", user.Username, user.Password, user.Role, user.Email)
+		err = db.Users.CreateUser(&user)
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, "Username is already taken", http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			log.Println("Database Insert Error: ", err)
@@ -62,12 +67,12 @@ func (db *AppHandler) Register() http.Handler {
 
 // Login godoc
 // @Summary Login a user
-// @Description Login a user and return a JWT token
+// @Description Login a user and return a short-lived JWT access token and a refresh token
 // @Tags auth
 // @Accept  json
 // @Produce  json
 // @Param user body models.User true "User info"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} models.TokenResponse
 // @Failure 400 {object} string
 // @Failure 401 {object} string
 // @Failure 500 {object} string
@@ -83,9 +88,8 @@ func (db *AppHandler) Login() http.Handler {
 		}
 		log.Println("Credentials decoded: ", creds)
 
-		var storedUser models.User
-		row := db.DB.QueryRow("SELECT id, username, password, role FROM users WHERE username = ?", creds.Username)
-		if err := row.Scan(&storedUser.ID, &storedUser.Username, &storedUser.Password, &storedUser.Role); err != nil {
+		storedUser, err := db.Users.GetUserByUsername(creds.Username)
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			log.Println("Error fetching user from database: ", err)
 			return
@@ -99,32 +103,27 @@ func (db *AppHandler) Login() http.Handler {
 		}
 		log.Println("Password matched for user: ", storedUser.Username)
 
-		expirationTime := time.Now().Add(24 * time.Hour)
-		claims := &models.Claims{
-			Username: storedUser.Username,
-			UserID:   storedUser.ID,
-			Role:     storedUser.Role,
-			StandardClaims: jwt.StandardClaims{
-				ExpiresAt: expirationTime.Unix(),
-			},
-		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, err := token.SignedString(jwtKey)
+		resp, expirationTime, err := db.issueTokens(storedUser, "")
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
-			log.Println("Error signing token: ", err)
+			log.Println("Error issuing tokens: ", err)
 			return
 		}
 		log.Println("Token generated for user: ", storedUser.Username)
 
+		// tarayıcıdaki betikler okuyamaz, başka sitelerden gelen isteklerle gönderilmez
 		http.SetCookie(w, &http.Cookie{
-			Name:    "token",
-			Value:   tokenString,
-			Expires: expirationTime,
+			Name:     "token",
+			Value:    resp.Token,
+			Path:     "/",
+			Expires:  expirationTime,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteStrictMode,
 		})
 		log.Println("Cookie set with token for user: ", storedUser.Username)
 
-		if err := json.NewEncoder(w).Encode(map[string]string{"token": tokenString}); err != nil {
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			log.Println("Error encoding response: ", err)
 		}
 	})
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"task-management-system/authz"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+// maxCommentLength is counted in characters, TEXT columns hold 65535 bytes.
+const maxCommentLength = 10000
+
+// kullanıcı adları ASCII dışı harfler de içerebilir, \w yalnızca ASCII eşler
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_.-]+)`)
+
+// parseMentions returns the usernames mentioned in the text, without
+// duplicates, in the order they appear.
+func parseMentions(text string) []string {
+	seen := map[string]bool{}
+	var usernames []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := strings.TrimRight(m[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// actorFor builds the policy view of a user other than the requesting one.
+func (db *AppHandler) actorFor(user models.User) (authz.Actor, error) {
+	actor := authz.Actor{UserID: user.ID, Role: user.Role, Permissions: map[string]bool{}}
+	role, err := db.Roles.GetRole(user.Role)
+	if errors.Is(err, store.ErrNotFound) {
+		return actor, nil
+	}
+	if err != nil {
+		return actor, err
+	}
+	for _, p := range role.Permissions {
+		actor.Permissions[p] = true
+	}
+	return actor, nil
+}
+
+// notifyMentions notifies the users mentioned in the comment of the event who
+// can see the task. Users already mentioned in the text an edit replaced
+// were notified for the earlier version and are left out.
+func (db *AppHandler) notifyMentions(ev models.Event) error {
+	var data models.CommentEvent
+	if err := json.Unmarshal(ev.Data, &data); err != nil {
+		return err
+	}
+	notified := map[string]bool{}
+	for _, username := range parseMentions(data.PreviousBody) {
+		notified[username] = true
+	}
+
+	var errs []error
+	for _, username := range parseMentions(data.Comment.Body) {
+		if notified[username] {
+			continue
+		}
+		user, err := db.Users.GetUserByUsername(username)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if user.ID == data.Comment.AuthorID {
+			continue
+		}
+		actor, err := db.actorFor(user)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !authz.Can(actor, authz.ActionView, data.Task) {
+			continue
+		}
+
+		// olay tekrar yayınlanırsa EventID aynı kişiye ikinci bildirimi engeller
+		err = db.Notifier.Notify(&models.Notification{
+			UserID:    user.ID,
+			Type:      models.NotificationMention,
+			TaskID:    data.Task.ID,
+			ActorID:   data.Comment.AuthorID,
+			Message:   fmt.Sprintf("You were mentioned in a comment on task #%d %q", data.Task.ID, data.Task.Title),
+			EventID:   ev.ID,
+			CreatedAt: ev.CreatedAt,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateComment(req models.CommentRequest) (string, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return "", errors.New("comment body is required")
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return "", fmt.Errorf("comment body is longer than %d characters", maxCommentLength)
+	}
+	return body, nil
+}
+
+// taskComment loads the comment named by the {comment_id} route variable.
+// Comments of other tasks are reported as not found.
+func (db *AppHandler) taskComment(r *http.Request, task models.Task) (models.Comment, int, error) {
+	commentID, err := strconv.Atoi(mux.Vars(r)["comment_id"])
+	if err != nil {
+		return models.Comment{}, http.StatusBadRequest, errors.New("Invalid comment ID")
+	}
+	comment, err := db.Comments.GetComment(commentID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && comment.TaskID != task.ID) {
+		return comment, http.StatusNotFound, errors.New("Comment not found")
+	}
+	if err != nil {
+		return comment, http.StatusInternalServerError, err
+	}
+	return comment, 0, nil
+}
+
+// GetComments godoc
+// @Summary List the comments of a task
+// @Description Get the comments of a task with their authors, oldest first
+// @Tags comments
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} models.Comment
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/comments [get]
+func (db *AppHandler) GetComments() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		comments, err := db.Comments.ListComments(task.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		authors := map[int]*models.UserSummary{}
+		for i := range comments {
+			author, ok := authors[comments[i].AuthorID]
+			if !ok {
+				if author, err = db.userSummary(comments[i].AuthorID); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				authors[comments[i].AuthorID] = author
+			}
+			comments[i].Author = author
+		}
+		if comments == nil {
+			comments = []models.Comment{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(comments)
+	})
+}
+
+// CreateComment godoc
+// @Summary Comment on a task
+// @Description Add a comment to a task. Users mentioned with @username who can see the task are notified. Publishes a comment.created event.
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param comment body models.CommentRequest true "Comment"
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/comments [post]
+func (db *AppHandler) CreateComment() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		var req models.CommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, err := validateComment(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		comment := models.Comment{
+			TaskID:    task.ID,
+			AuthorID:  r.Context().Value("userID").(int),
+			Body:      body,
+			CreatedAt: time.Now(),
+		}
+		event := commentEvent(models.EventCommentCreated, comment.AuthorID, task, &comment, "")
+		if err := db.Comments.CreateComment(&comment, event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(comment)
+	})
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Change the text of one of the user's comments. The previous text is kept in the edit history. Only newly mentioned users are notified. Publishes a comment.updated event.
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Param comment body models.CommentRequest true "Comment"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/comments/{comment_id} [patch]
+func (db *AppHandler) UpdateComment() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		comment, code, err := db.taskComment(r, task)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		if comment.AuthorID != r.Context().Value("userID").(int) {
+			http.Error(w, "Only the author can edit a comment", http.StatusForbidden)
+			return
+		}
+
+		var req models.CommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, err := validateComment(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body == comment.Body {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(comment)
+			return
+		}
+
+		now := time.Now()
+		previous := comment.Body
+		comment.Body, comment.UpdatedAt = body, &now
+		event := commentEvent(models.EventCommentUpdated, comment.AuthorID, task, &comment, previous)
+		err = db.Comments.UpdateComment(comment.ID, body, now, event)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(comment)
+	})
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Delete a comment and its edit history. Authors can delete their own comments, users who can edit the task any comment on it. Publishes a comment.deleted event.
+// @Tags comments
+// @Param task_id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/comments/{comment_id} [delete]
+func (db *AppHandler) DeleteComment() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		comment, code, err := db.taskComment(r, task)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		actor := authz.ActorFromContext(r.Context())
+		if comment.AuthorID != actor.UserID && !authz.Can(actor, authz.ActionUpdate, task) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		err = db.Comments.DeleteComment(comment.ID, commentEvent(models.EventCommentDeleted, actor.UserID, task, &comment, ""))
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetCommentEdits godoc
+// @Summary Get the edit history of a comment
+// @Description Get the earlier versions of a comment, oldest first
+// @Tags comments
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {array} models.CommentEdit
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/comments/{comment_id}/edits [get]
+func (db *AppHandler) GetCommentEdits() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		comment, code, err := db.taskComment(r, task)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		edits, err := db.Comments.ListCommentEdits(comment.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if edits == nil {
+			edits = []models.CommentEdit{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(edits)
+	})
+}
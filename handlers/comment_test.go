@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"task-management-system/models"
+	"testing"
+	"time"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"no mentions here", nil},
+		{"@alice", []string{"alice"}},
+		{"hi @alice and @bob", []string{"alice", "bob"}},
+		{"@alice @bob @alice", []string{"alice", "bob"}},
+		{"thanks @alice.", []string{"alice"}},
+		{"ask @bob-, then @carol...", []string{"bob", "carol"}},
+		{"@john.doe and @jane_doe-2", []string{"john.doe", "jane_doe-2"}},
+		{"(@alice) @bob, @carol!", []string{"alice", "bob", "carol"}},
+		{"line one\n@alice", []string{"alice"}},
+		{"mail alice@example.com", nil},
+		{"mail müşteri@example.com", nil},
+		{"@@alice", nil},
+		{"@ alice", nil},
+		{"@. @-", nil},
+		{"@Alice @alice", []string{"Alice", "alice"}},
+		{"@ayşe ile @zoë", []string{"ayşe", "zoë"}},
+	}
+	for _, tt := range tests {
+		if got := parseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMentions(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// publish hands the events not published yet to NotifyEvent, the way the
+// dispatcher does, and returns them.
+func (a *testApp) publish(t *testing.T) []models.Event {
+	t.Helper()
+	evs, err := a.outbox.ListUnpublishedEvents(time.Now(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range evs {
+		if err := a.NotifyEvent(ev); err != nil {
+			t.Fatalf("NotifyEvent(%s): %v", ev.Type, err)
+		}
+	}
+	return evs
+}
+
+// mentions returns the number of mention notifications of each user.
+func (a *testApp) mentions(t *testing.T, users ...models.User) []int {
+	t.Helper()
+	var counts []int
+	for _, user := range users {
+		notifications, err := a.Notifications.ListNotifications(user.ID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, notification := range notifications {
+			if notification.Type == models.NotificationMention {
+				n++
+			}
+		}
+		counts = append(counts, n)
+	}
+	return counts
+}
+
+func TestCommentEvents(t *testing.T) {
+	a := newTestApp(t)
+	alice, aliceToken := a.signUp(t, "alice", "admin")
+	bob, bobToken := a.signUp(t, "bob", defaultRole)
+	carol, _ := a.signUp(t, "carol", defaultRole)
+	task := models.Task{Title: "Plan", Status: "pending", Priority: models.PriorityMedium, UserID: alice.ID, AssignedTo: bob.ID}
+	if err := a.Tasks.CreateTask(&task, nil); err != nil {
+		t.Fatal(err)
+	}
+	path := "/tasks/" + strconv.Itoa(task.ID) + "/comments"
+
+	// carol cannot see the task, the author and unknown users are skipped
+	w := a.do(t, aliceToken, "POST", path, models.CommentRequest{Body: "@bob @carol @alice @nobody please look"})
+	expectStatus(t, w, http.StatusCreated)
+	var comment models.Comment
+	decode(t, w, &comment)
+	evs := a.publish(t)
+	if len(evs) != 1 || evs[0].Type != models.EventCommentCreated {
+		t.Fatalf("events = %v, want comment.created", a.eventTypes(t))
+	}
+	var data models.CommentEvent
+	if err := json.Unmarshal(evs[0].Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Comment.ID != comment.ID || data.Task.ID != task.ID || evs[0].ActorID != alice.ID {
+		t.Errorf("comment.created = %+v by %d", data, evs[0].ActorID)
+	}
+	if got := a.mentions(t, alice, bob, carol); !reflect.DeepEqual(got, []int{0, 1, 0}) {
+		t.Errorf("mentions of alice, bob, carol = %v, want [0 1 0]", got)
+	}
+
+	// an event published again notifies nobody twice
+	for _, ev := range evs {
+		if err := a.NotifyEvent(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := a.mentions(t, bob); got[0] != 1 {
+		t.Errorf("bob has %d mentions after the event was published again", got[0])
+	}
+
+	// bob's comment mentions alice, the edits only notify her once
+	w = a.do(t, bobToken, "POST", path, models.CommentRequest{Body: "done"})
+	expectStatus(t, w, http.StatusCreated)
+	decode(t, w, &comment)
+	commentPath := path + "/" + strconv.Itoa(comment.ID)
+	for _, body := range []string{"done @alice", "done, @alice!", "done, @alice and @bob"} {
+		expectStatus(t, a.do(t, bobToken, "PATCH", commentPath, models.CommentRequest{Body: body}), http.StatusOK)
+	}
+	if err := a.outbox.MarkEventsPublished(eventIDs(evs), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	evs = a.publish(t)
+	types := []string{}
+	for _, ev := range evs {
+		types = append(types, ev.Type)
+	}
+	want := []string{models.EventCommentCreated, models.EventCommentUpdated, models.EventCommentUpdated, models.EventCommentUpdated}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("events = %v, want %v", types, want)
+	}
+	if got := a.mentions(t, alice, bob); !reflect.DeepEqual(got, []int{1, 1}) {
+		t.Errorf("mentions of alice, bob = %v, want [1 1]", got)
+	}
+
+	// the task owner deletes bob's comment
+	expectStatus(t, a.do(t, aliceToken, "DELETE", commentPath, nil), http.StatusNoContent)
+	if err := a.outbox.MarkEventsPublished(eventIDs(evs), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	evs = a.publish(t)
+	if len(evs) != 1 || evs[0].Type != models.EventCommentDeleted || evs[0].ActorID != alice.ID {
+		t.Fatalf("events = %+v, want one comment.deleted by alice", evs)
+	}
+	if err := json.Unmarshal(evs[0].Data, &data); err != nil || data.Comment.Body != "done, @alice and @bob" {
+		t.Errorf("comment.deleted carries %+v, %v", data.Comment, err)
+	}
+}
+
+func TestCommentEventVisibility(t *testing.T) {
+	task := models.Task{ID: 1, UserID: 1, AssignedTo: 2}
+	ev := models.Event{Type: models.EventCommentCreated}
+	var err error
+	if ev.Data, err = json.Marshal(models.CommentEvent{Task: task, Comment: models.Comment{TaskID: 1, AuthorID: 1, Body: "hi"}}); err != nil {
+		t.Fatal(err)
+	}
+	a := newTestApp(t)
+	for id, want := range map[int]bool{1: true, 2: true, 3: false} {
+		actor, err := a.actorFor(models.User{ID: id, Role: defaultRole})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := canSeeEvent(actor, ev); got != want {
+			t.Errorf("user %d sees the comment event: %v, want %v", id, got, want)
+		}
+	}
+}
+
+func eventIDs(evs []models.Event) []int64 {
+	var ids []int64
+	for _, ev := range evs {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
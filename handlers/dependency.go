@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"task-management-system/authz"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// blockedError is returned for a status change that open blockers prevent.
+type blockedError struct {
+	To       string
+	Blockers []int
+}
+
+func (e *blockedError) Error() string {
+	ids := make([]string, len(e.Blockers))
+	for i, id := range e.Blockers {
+		ids[i] = strconv.Itoa(id)
+	}
+	return fmt.Sprintf("cannot change status to %q, blocked by open tasks: %s", e.To, strings.Join(ids, ", "))
+}
+
+// openBlockers returns the blockers of the task that are not terminal yet.
+// Blockers in the trash are ignored.
+func (db *AppHandler) openBlockers(taskID int) ([]int, error) {
+	ids, err := db.Dependencies.ListBlockers(taskID)
+	if err != nil {
+		return nil, err
+	}
+	var open []int
+	for _, id := range ids {
+		blocker, err := db.Tasks.GetTask(id)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if blocker.DeletedAt == nil && !db.Workflow.IsTerminal(blocker.Status) {
+			open = append(open, id)
+		}
+	}
+	return open, nil
+}
+
+// GetDependencies godoc
+// @Summary Get the dependency graph of a task
+// @Description Get every task that blocks this task or is blocked by it, directly or through other tasks. Tasks in the trash are left out.
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {object} models.DependencyGraph
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/dependencies [get]
+func (db *AppHandler) GetDependencies() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		actor := authz.ActorFromContext(r.Context())
+
+		graph := models.DependencyGraph{TaskID: task.ID, Nodes: []models.DependencyNode{}, Edges: []models.TaskDependency{}}
+		tasks := map[int]models.Task{task.ID: task}
+		skipped := map[int]bool{}
+		queue := []int{task.ID}
+		// iki yönde de gezerek bağlı bütün görevleri topla
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+
+			blockers, err := db.Dependencies.ListBlockers(id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			blocked, err := db.Dependencies.ListBlockedTasks(id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			for _, other := range append(blockers, blocked...) {
+				if _, ok := tasks[other]; ok || skipped[other] {
+					continue
+				}
+				t, err := db.Tasks.GetTask(other)
+				if err != nil && !errors.Is(err, store.ErrNotFound) {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if err != nil || t.DeletedAt != nil {
+					skipped[other] = true
+					continue
+				}
+				tasks[other] = t
+				queue = append(queue, other)
+			}
+			// her kenar engellenen görevin tarafından bir kez eklenir
+			for _, b := range blockers {
+				if _, ok := tasks[b]; ok {
+					graph.Edges = append(graph.Edges, models.TaskDependency{TaskID: id, BlockedBy: b})
+				}
+			}
+		}
+
+		for _, t := range tasks {
+			node := models.DependencyNode{ID: t.ID, Open: !db.Workflow.IsTerminal(t.Status)}
+			if authz.Can(actor, authz.ActionView, t) {
+				node.Title, node.Status = t.Title, t.Status
+			}
+			graph.Nodes = append(graph.Nodes, node)
+		}
+		sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(graph)
+	})
+}
+
+// AddDependency godoc
+// @Summary Add a dependency
+// @Description Mark this task as blocked by another task. It cannot move to the statuses in workflow.requires_unblocked (in_progress and completed by default) until the blocker reaches a terminal status (completed or cancelled by default). Dependencies that would form a cycle are rejected with 422.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param dependency body models.DependencyRequest true "Blocking task"
+// @Success 201 {object} models.TaskDependency
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 409 {object} string
+// @Failure 422 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/dependencies [post]
+func (db *AppHandler) AddDependency() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		var req models.DependencyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.BlockedBy == task.ID {
+			http.Error(w, "A task cannot block itself", http.StatusUnprocessableEntity)
+			return
+		}
+
+		blocker, err := db.Tasks.GetTask(req.BlockedBy)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && blocker.DeletedAt != nil) {
+			http.Error(w, "Blocking task not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !authz.Can(authz.ActorFromContext(r.Context()), authz.ActionView, blocker) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		// blocker zaten bu göreve bağlıysa yeni kenar döngü oluşturur
+		err = db.Dependencies.AddTaskDependency(task.ID, blocker.ID, time.Now())
+		if errors.Is(err, store.ErrDependencyCycle) {
+			http.Error(w, fmt.Sprintf("task %d already depends on task %d", blocker.ID, task.ID), http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, "Dependency already exists", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(models.TaskDependency{TaskID: task.ID, BlockedBy: blocker.ID})
+	})
+}
+
+// RemoveDependency godoc
+// @Summary Remove a dependency
+// @Description Stop waiting for the blocking task
+// @Tags tasks
+// @Param task_id path int true "Task ID"
+// @Param blocker_id path int true "Blocking task ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/dependencies/{blocker_id} [delete]
+func (db *AppHandler) RemoveDependency() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		blockerID, err := strconv.Atoi(mux.Vars(r)["blocker_id"])
+		if err != nil {
+			http.Error(w, "Invalid blocker ID", http.StatusBadRequest)
+			return
+		}
+
+		err = db.Dependencies.RemoveTaskDependency(task.ID, blockerID)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Dependency not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
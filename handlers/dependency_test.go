@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"testing"
+	"time"
+)
+
+func TestAddDependency(t *testing.T) {
+	a := newTestApp(t)
+	alice, token := a.signUp(t, "alice", "admin")
+	first := a.newTask(t, "first", alice.ID)
+	second := a.newTask(t, "second", alice.ID)
+	third := a.newTask(t, "third", alice.ID)
+	add := func(task, blocker models.Task) int {
+		return a.do(t, token, "POST", "/tasks/"+strconv.Itoa(task.ID)+"/dependencies", models.DependencyRequest{BlockedBy: blocker.ID}).Code
+	}
+	if code := add(second, first); code != http.StatusCreated {
+		t.Fatalf("second blocked by first: status %d", code)
+	}
+	if code := add(third, second); code != http.StatusCreated {
+		t.Fatalf("third blocked by second: status %d", code)
+	}
+
+	tests := []struct {
+		name          string
+		task, blocker models.Task
+		want          int
+	}{
+		{"existing", second, first, http.StatusConflict},
+		{"itself", first, first, http.StatusUnprocessableEntity},
+		{"direct cycle", first, second, http.StatusUnprocessableEntity},
+		{"cycle through another task", first, third, http.StatusUnprocessableEntity},
+		{"missing blocker", first, models.Task{ID: 99}, http.StatusNotFound},
+		{"shortcut", third, first, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if code := add(tt.task, tt.blocker); code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, code, tt.want)
+		}
+	}
+	if blockers, _ := a.Dependencies.ListBlockers(first.ID); len(blockers) != 0 {
+		t.Errorf("first is blocked by %v", blockers)
+	}
+}
+
+func TestTransitionWaitsForBlockers(t *testing.T) {
+	a := newTestApp(t)
+	alice, token := a.signUp(t, "alice", "admin")
+	blocker := a.newTask(t, "blocker", alice.ID)
+	task := a.newTask(t, "task", alice.ID)
+	if err := a.Dependencies.AddTaskDependency(task.ID, blocker.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	move := func(task models.Task, to string) int {
+		return a.do(t, token, "POST", "/tasks/"+strconv.Itoa(task.ID)+"/transitions", models.TransitionRequest{To: to}).Code
+	}
+
+	// only the statuses in requires_unblocked wait for the blocker
+	if code := move(task, "in_progress"); code != http.StatusUnprocessableEntity {
+		t.Errorf("to in_progress with an open blocker: status %d, want 422", code)
+	}
+	if code := move(task, "blocked"); code != http.StatusOK {
+		t.Errorf("to blocked: status %d, want 200", code)
+	}
+	if code := move(blocker, "cancelled"); code != http.StatusOK {
+		t.Fatalf("cancelling the blocker: status %d", code)
+	}
+	if code := move(task, "in_progress"); code != http.StatusOK {
+		t.Errorf("to in_progress after the blocker was cancelled: status %d, want 200", code)
+	}
+}
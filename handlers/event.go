@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"task-management-system/authz"
+	"task-management-system/events"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+)
+
+// taskEvent returns the event of a task change with its history entries:
+// the fields that differ between before and after. before is nil for a new
+// task, after is nil for a deleted one. A restored task gets a single entry.
+// It is nil when an update changed none of the tracked fields. Both are built
+// when the store saves the change, so a new task already has its ID.
+func taskEvent(actorID int, before, after *models.Task) store.EventFunc {
+	var changes []models.FieldChange
+	if before != nil && after != nil && (before.DeletedAt == nil || after.DeletedAt != nil) {
+		oldFields, newFields := taskFields(*before), taskFields(*after)
+		for i := range oldFields {
+			if oldFields[i][1] != newFields[i][1] {
+				changes = append(changes, models.FieldChange{Field: oldFields[i][0], OldValue: oldFields[i][1], NewValue: newFields[i][1]})
+			}
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+	return func() (models.Event, []models.TaskHistoryEntry, error) {
+		now := time.Now()
+		var ev models.Event
+		var entries []models.TaskHistoryEntry
+		var err error
+		switch {
+		case before == nil:
+			ev, err = events.New(models.EventTaskCreated, actorID, models.TaskEvent{Task: *after})
+			for _, f := range taskFields(*after) {
+				entries = append(entries, models.TaskHistoryEntry{TaskID: after.ID, ActorID: actorID, Action: models.HistoryCreated, Field: f[0], NewValue: f[1], CreatedAt: now})
+			}
+		case after == nil:
+			ev, err = events.New(models.EventTaskDeleted, actorID, models.TaskEvent{Task: *before})
+			entries = append(entries, models.TaskHistoryEntry{TaskID: before.ID, ActorID: actorID, Action: models.HistoryDeleted, CreatedAt: now})
+		case before.DeletedAt != nil && after.DeletedAt == nil:
+			ev, err = events.New(models.EventTaskRestored, actorID, models.TaskEvent{Task: *after})
+			entries = append(entries, models.TaskHistoryEntry{TaskID: after.ID, ActorID: actorID, Action: models.HistoryRestored, CreatedAt: now})
+		default:
+			ev, err = events.New(models.EventTaskUpdated, actorID, models.TaskEvent{Task: *after, Changes: changes})
+			for _, c := range changes {
+				entries = append(entries, models.TaskHistoryEntry{TaskID: after.ID, ActorID: actorID, Action: models.HistoryUpdated, Field: c.Field, OldValue: c.OldValue, NewValue: c.NewValue, CreatedAt: now})
+			}
+		}
+		return ev, entries, err
+	}
+}
+
+// friendshipEvent returns the event of a friendship change. The friendship is
+// read when the store saves the change, so a new request already has its ID.
+func friendshipEvent(eventType string, actorID int, friendship *models.Friendship) store.EventFunc {
+	return func() (models.Event, []models.TaskHistoryEntry, error) {
+		ev, err := events.New(eventType, actorID, *friendship)
+		return ev, nil, err
+	}
+}
+
+// commentEvent returns the event of a comment change. The comment is read
+// when the store saves the change, so a new comment already has its ID.
+// previousBody is the text an edit replaced.
+func commentEvent(eventType string, actorID int, task models.Task, comment *models.Comment, previousBody string) store.EventFunc {
+	return func() (models.Event, []models.TaskHistoryEntry, error) {
+		ev, err := events.New(eventType, actorID, models.CommentEvent{Task: task, Comment: *comment, PreviousBody: previousBody})
+		return ev, nil, err
+	}
+}
+
+// canSeeEvent reports whether the event may be shown to the actor: task and
+// comment events to users who can view the task, or could before an update
+// took it from them, friendship events to the two users involved and to user
+// admins.
+func canSeeEvent(actor authz.Actor, ev models.Event) bool {
+	switch ev.Type {
+	case models.EventFriendshipRequested, models.EventFriendshipAccepted:
+		var f models.Friendship
+		if err := json.Unmarshal(ev.Data, &f); err != nil {
+			return false
+		}
+		return f.UserID == actor.UserID || f.FriendID == actor.UserID || actor.Has(models.PermUserAdmin)
+	default:
+		var data models.TaskEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return false
+		}
+		if authz.Can(actor, authz.ActionView, data.Task) {
+			return true
+		}
+		// başkasına atanan görevin eski atanan kişisi de değişikliği görür
+		for _, c := range data.Changes {
+			if c.Field == "assigned_to" && c.OldValue == strconv.Itoa(actor.UserID) {
+				return actor.Has(models.PermTaskRead)
+			}
+		}
+		return false
+	}
+}
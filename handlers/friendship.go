@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"task-management-system/models"
+	"task-management-system/store"
 )
 
 // CreateFriendship godoc
@@ -29,11 +31,12 @@ func (db *AppHandler) CreateFriendship() http.Handler {
 		friendship.UserID = userID
 		friendship.Status = "pending"
 
-		_, err := db.DB.Exec("INSERT INTO friendships (user_id, friend_id, status) VALUES (?, ?, ?)", friendship.UserID, friendship.FriendID, friendship.Status)
-		if err != nil {
+		event := friendshipEvent(models.EventFriendshipRequested, userID, &friendship)
+		if err := db.Friendships.CreateFriendship(&friendship, event); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		db.Events.Wake()
 
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(friendship)
@@ -42,13 +45,14 @@ func (db *AppHandler) CreateFriendship() http.Handler {
 
 // AcceptFriendRequest godoc
 // @Summary Accept a friendship request
-// @Description Accept a friendship request by updating status to accepted
+// @Description Accept a pending friendship request by updating status to accepted
 // @Tags friendship
 // @Accept  json
 // @Produce  json
 // @Param friendship body models.Friendship true "Friendship info"
 // @Success 200 {object} models.Friendship
 // @Failure 400 {object} string
+// @Failure 404 {object} string
 // @Failure 500 {object} string
 // @Router /friends/accept [post]
 func (db *AppHandler) AcceptFriendRequest() http.Handler {
@@ -60,13 +64,20 @@ func (db *AppHandler) AcceptFriendRequest() http.Handler {
 		}
 
 		userID := r.Context().Value("userID").(int)
-		_, err := db.DB.Exec("UPDATE friendships SET status = 'accepted' WHERE friend_id = ? AND user_id = ?", userID, friendship.UserID)
+		friendship = models.Friendship{UserID: friendship.UserID, FriendID: userID}
+		// olay yalnızca bekleyen isteği gerçekten kabul eden istekte kaydedilir
+		event := friendshipEvent(models.EventFriendshipAccepted, userID, &friendship)
+		err := db.Friendships.AcceptFriendship(&friendship, event)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Friend request not found", http.StatusNotFound)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		db.Events.Wake()
 
-		friendship.Status = "accepted"
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(friendship)
 	})
@@ -92,8 +103,7 @@ func (db *AppHandler) RejectFriendRequest() http.Handler {
 		}
 
 		userID := r.Context().Value("userID").(int)
-		_, err := db.DB.Exec("UPDATE friendships SET status = 'rejected' WHERE friend_id = ? AND user_id = ?", userID, friendship.UserID)
-		if err != nil {
+		if err := db.Friendships.UpdateFriendshipStatus(friendship.UserID, userID, "rejected", nil); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"task-management-system/authz"
+	"task-management-system/events"
+	"task-management-system/middleware"
+	"task-management-system/models"
+	"task-management-system/notify"
+	"task-management-system/store"
+	"task-management-system/workflow"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMain(m *testing.M) {
+	// handlers log every request
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testApp is an AppHandler on the memory stores behind the routes and
+// middleware of main.go.
+type testApp struct {
+	*AppHandler
+	outbox *store.MemoryOutboxStore
+	router *mux.Router
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	outbox := store.NewMemoryOutboxStore()
+	history := store.NewMemoryTaskHistoryStore()
+	labels := store.NewMemoryLabelStore()
+	tasks := store.NewMemoryTaskStore()
+	tasks.Events, tasks.History, tasks.Labels = outbox, history, labels
+	friendships := store.NewMemoryFriendshipStore()
+	friendships.Events = outbox
+	revocations := store.NewMemoryRevocationStore()
+	roles := store.NewMemoryRoleStore()
+	users := store.NewMemoryUserStore()
+	comments := store.NewMemoryCommentStore()
+	comments.Events = outbox
+	notifications := store.NewMemoryNotificationStore()
+
+	h := &AppHandler{
+		Tasks:           tasks,
+		History:         history,
+		Dependencies:    store.NewMemoryTaskDependencyStore(),
+		Labels:          labels,
+		Users:           users,
+		Comments:        comments,
+		Notifications:   notifications,
+		Notifier:        notify.NewService(notifications, users, nil, []string{models.ChannelInApp}),
+		Friendships:     friendships,
+		RefreshTokens:   store.NewMemoryRefreshTokenStore(),
+		Revocations:     revocations,
+		Roles:           roles,
+		Outbox:          outbox,
+		Events:          events.NewDispatcher(outbox, events.NewBus(), time.Minute),
+		JWTKey:          []byte("test"),
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour,
+		Workflow:        workflow.Default(),
+	}
+
+	auth := middleware.JWTMiddleware(h.JWTKey, revocations)
+	can := func(permissions ...string) func(http.Handler) http.Handler {
+		return middleware.RequirePermission(roles, permissions...)
+	}
+	task := func(actions ...authz.Action) func(http.Handler) http.Handler {
+		return middleware.RequireTaskAccess(tasks, actions...)
+	}
+	trashedTask := func(actions ...authz.Action) func(http.Handler) http.Handler {
+		return middleware.RequireTrashedTaskAccess(tasks, actions...)
+	}
+
+	r := mux.NewRouter()
+	r.Handle("/register", h.Register()).Methods("POST")
+	r.Handle("/login", h.Login()).Methods("POST")
+	r.Handle("/token/refresh", h.RefreshToken()).Methods("POST")
+	r.Handle("/logout", auth(h.Logout())).Methods("POST")
+	r.Handle("/users/{user_id}/sessions/revoke", auth(can(models.PermUserAdmin)(h.RevokeUserSessions()))).Methods("POST")
+	r.Handle("/tasks", auth(can(models.PermTaskCreate)(h.CreateTask()))).Methods("POST")
+	r.Handle("/tasks/{task_id}", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(h.GetTask())))).Methods("GET")
+	r.Handle("/tasks/{task_id}", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn, models.PermTaskTransition)(
+		task(authz.ActionUpdate, authz.ActionTransition)(h.UpdateTask())))).Methods("PUT")
+	r.Handle("/tasks/{task_id}", auth(can(models.PermTaskDeleteAny, models.PermTaskDeleteOwn)(
+		task(authz.ActionDelete)(h.DeleteTask())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/restore", auth(can(models.PermTaskDeleteAny, models.PermTaskDeleteOwn)(
+		trashedTask(authz.ActionDelete)(h.RestoreTask())))).Methods("POST")
+	r.Handle("/tasks", auth(can(models.PermTaskRead, models.PermTaskReadAny)(h.GetTasks()))).Methods("GET")
+	r.Handle("/tasks/{task_id}/history", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		trashedTask(authz.ActionView)(h.GetTaskHistory())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/transitions", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn, models.PermTaskTransition)(
+		task(authz.ActionTransition)(h.TransitionTask())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/dependencies", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(h.GetDependencies())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/dependencies", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(h.AddDependency())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/dependencies/{blocker_id}", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(h.RemoveDependency())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/comments", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(h.GetComments())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/comments", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(h.CreateComment())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/comments/{comment_id}", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(h.UpdateComment())))).Methods("PATCH")
+	r.Handle("/tasks/{task_id}/comments/{comment_id}", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(h.DeleteComment())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/subtasks", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(h.GetSubtasks())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/subtasks", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(h.AttachSubtask())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/subtasks/{subtask_id}", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(h.DetachSubtask())))).Methods("DELETE")
+	r.Handle("/events", auth(h.StreamEvents())).Methods("GET")
+	r.Handle("/friends", auth(can(models.PermFriendshipManage)(h.CreateFriendship()))).Methods("POST")
+	r.Handle("/friends/accept", auth(can(models.PermFriendshipManage)(h.AcceptFriendRequest()))).Methods("POST")
+	r.Handle("/friends/reject", auth(can(models.PermFriendshipManage)(h.RejectFriendRequest()))).Methods("POST")
+
+	return &testApp{AppHandler: h, outbox: outbox, router: r}
+}
+
+// do sends a request with the body encoded as JSON. An empty token sends
+// no Authorization header.
+func (a *testApp) do(t *testing.T, token, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d: %s", w.Code, want, w.Body.String())
+	}
+}
+
+// signUp registers the user, gives it the role and logs it in.
+func (a *testApp) signUp(t *testing.T, username, role string) (models.User, string) {
+	t.Helper()
+	user, tokens := a.signUpTokens(t, username, role)
+	return user, tokens.Token
+}
+
+// signUpTokens is signUp returning the refresh token as well.
+func (a *testApp) signUpTokens(t *testing.T, username, role string) (models.User, models.TokenResponse) {
+	t.Helper()
+	w := a.do(t, "", "POST", "/register", models.User{Username: username, Password: "secret", Email: username + "@example.com"})
+	expectStatus(t, w, http.StatusCreated)
+	var user models.User
+	decode(t, w, &user)
+	if role != defaultRole {
+		if err := a.Users.SetUserRole(user.ID, role); err != nil {
+			t.Fatal(err)
+		}
+		user.Role = role
+	}
+
+	w = a.do(t, "", "POST", "/login", models.User{Username: username, Password: "secret"})
+	expectStatus(t, w, http.StatusOK)
+	var tokens models.TokenResponse
+	decode(t, w, &tokens)
+	return user, tokens
+}
+
+func (a *testApp) eventTypes(t *testing.T) []string {
+	t.Helper()
+	evs, err := a.outbox.ListUnpublishedEvents(time.Now(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, ev := range evs {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	a := newTestApp(t)
+
+	// istemcinin seçtiği rol dikkate alınmaz
+	w := a.do(t, "", "POST", "/register", models.User{Username: "alice", Password: "secret", Role: "admin"})
+	expectStatus(t, w, http.StatusCreated)
+	var user models.User
+	decode(t, w, &user)
+	if user.ID == 0 || user.Role != defaultRole || user.Password == "secret" {
+		t.Errorf("registered %+v, want an ID, the default role and a hashed password", user)
+	}
+
+	w = a.do(t, "", "POST", "/register", models.User{Username: "alice", Password: "other"})
+	expectStatus(t, w, http.StatusConflict)
+
+	w = a.do(t, "", "POST", "/login", models.User{Username: "alice", Password: "wrong"})
+	expectStatus(t, w, http.StatusUnauthorized)
+	w = a.do(t, "", "POST", "/login", models.User{Username: "nobody", Password: "secret"})
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	w = a.do(t, "", "POST", "/login", models.User{Username: "alice", Password: "secret"})
+	expectStatus(t, w, http.StatusOK)
+	var tokens models.TokenResponse
+	decode(t, w, &tokens)
+	if tokens.Token == "" || tokens.RefreshToken == "" {
+		t.Fatalf("login returned %+v", tokens)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != tokens.Token || !cookies[0].HttpOnly {
+		t.Errorf("cookies = %+v", cookies)
+	}
+
+	expectStatus(t, a.do(t, tokens.Token, "GET", "/tasks", nil), http.StatusOK)
+	expectStatus(t, a.do(t, "", "GET", "/tasks", nil), http.StatusUnauthorized)
+	expectStatus(t, a.do(t, "garbage", "GET", "/tasks", nil), http.StatusUnauthorized)
+}
+
+func TestCreateTask(t *testing.T) {
+	a := newTestApp(t)
+	alice, aliceToken := a.signUp(t, "alice", "admin")
+	bob, bobToken := a.signUp(t, "bob", defaultRole)
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	w := a.do(t, aliceToken, "POST", "/tasks", models.Task{Title: "Write tests", StartDate: start, DueDate: start.AddDate(0, 0, 7), AssignedTo: bob.ID})
+	expectStatus(t, w, http.StatusCreated)
+	var task models.Task
+	decode(t, w, &task)
+	if task.ID == 0 || task.UserID != alice.ID || task.Status != "pending" || task.Priority != models.PriorityMedium {
+		t.Errorf("created %+v, want an ID, alice as the creator and the default status and priority", task)
+	}
+	if stored, err := a.Tasks.GetTask(task.ID); err != nil || stored.Title != "Write tests" {
+		t.Errorf("stored %+v, %v", stored, err)
+	}
+	if got := a.eventTypes(t); len(got) != 1 || got[0] != models.EventTaskCreated {
+		t.Errorf("events = %v, want task.created", got)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		task  models.Task
+		want  int
+	}{
+		{"unknown status", aliceToken, models.Task{Title: "x", Status: "someday"}, http.StatusBadRequest},
+		{"unknown priority", aliceToken, models.Task{Title: "x", Priority: "asap"}, http.StatusBadRequest},
+		{"missing parent", aliceToken, models.Task{Title: "x", ParentID: new(int)}, http.StatusNotFound},
+		{"without task:create", bobToken, models.Task{Title: "x"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := a.do(t, tt.token, "POST", "/tasks", tt.task); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.want, w.Body.String())
+		}
+	}
+	if page, _ := a.Tasks.ListTasks(store.TaskQuery{}); page.Total != 1 {
+		t.Errorf("%d tasks stored, want only the valid one", page.Total)
+	}
+}
+
+func TestTaskAccess(t *testing.T) {
+	a := newTestApp(t)
+	alice, aliceToken := a.signUp(t, "alice", "admin")
+	bob, bobToken := a.signUp(t, "bob", defaultRole)
+	_, carolToken := a.signUp(t, "carol", defaultRole)
+
+	w := a.do(t, aliceToken, "POST", "/tasks", models.Task{Title: "Review", AssignedTo: bob.ID})
+	expectStatus(t, w, http.StatusCreated)
+	var task models.Task
+	decode(t, w, &task)
+	path := "/tasks/" + strconv.Itoa(task.ID)
+
+	// the creator and the assignee see the task, others do not
+	w = a.do(t, bobToken, "GET", path, nil)
+	expectStatus(t, w, http.StatusOK)
+	var detail models.TaskDetail
+	decode(t, w, &detail)
+	if detail.Creator == nil || detail.Creator.ID != alice.ID || detail.Assignee == nil || detail.Assignee.ID != bob.ID {
+		t.Errorf("detail = %+v", detail)
+	}
+	expectStatus(t, a.do(t, carolToken, "GET", path, nil), http.StatusForbidden)
+	expectStatus(t, a.do(t, aliceToken, "GET", "/tasks/999", nil), http.StatusNotFound)
+	expectStatus(t, a.do(t, aliceToken, "GET", "/tasks/abc", nil), http.StatusBadRequest)
+
+	for token, want := range map[string]int{aliceToken: 1, bobToken: 1, carolToken: 0} {
+		w := a.do(t, token, "GET", "/tasks", nil)
+		expectStatus(t, w, http.StatusOK)
+		var tasks []models.Task
+		decode(t, w, &tasks)
+		if len(tasks) != want || w.Header().Get("X-Total-Count") != strconv.Itoa(want) {
+			t.Errorf("listed %d tasks (X-Total-Count %s), want %d", len(tasks), w.Header().Get("X-Total-Count"), want)
+		}
+	}
+
+	// the assignee may only change the status
+	expectStatus(t, a.do(t, bobToken, "PUT", path, models.Task{Title: "Renamed"}), http.StatusForbidden)
+	w = a.do(t, bobToken, "PUT", path, models.Task{Status: "in_progress"})
+	expectStatus(t, w, http.StatusOK)
+	expectStatus(t, a.do(t, bobToken, "PUT", path, models.Task{Status: "completed"}), http.StatusUnprocessableEntity)
+	expectStatus(t, a.do(t, carolToken, "PUT", path, models.Task{Status: "review"}), http.StatusForbidden)
+	w = a.do(t, aliceToken, "PUT", path, models.Task{Title: "Renamed", Priority: models.PriorityHigh})
+	expectStatus(t, w, http.StatusOK)
+	stored, _ := a.Tasks.GetTask(task.ID)
+	if stored.Title != "Renamed" || stored.Priority != models.PriorityHigh || stored.Status != "in_progress" || stored.UserID != alice.ID {
+		t.Errorf("stored %+v", stored)
+	}
+}
+
+func TestDeleteAndRestoreTask(t *testing.T) {
+	a := newTestApp(t)
+	_, aliceToken := a.signUp(t, "alice", "admin")
+	bob, bobToken := a.signUp(t, "bob", defaultRole)
+
+	w := a.do(t, aliceToken, "POST", "/tasks", models.Task{Title: "Cleanup", AssignedTo: bob.ID})
+	expectStatus(t, w, http.StatusCreated)
+	var task models.Task
+	decode(t, w, &task)
+	path := "/tasks/" + strconv.Itoa(task.ID)
+
+	expectStatus(t, a.do(t, bobToken, "DELETE", path, nil), http.StatusForbidden)
+	expectStatus(t, a.do(t, aliceToken, "POST", path+"/restore", nil), http.StatusConflict)
+	expectStatus(t, a.do(t, aliceToken, "DELETE", path, nil), http.StatusOK)
+	expectStatus(t, a.do(t, aliceToken, "GET", path, nil), http.StatusNotFound)
+	expectStatus(t, a.do(t, aliceToken, "DELETE", path, nil), http.StatusNotFound)
+
+	w = a.do(t, aliceToken, "GET", "/tasks?trashed=true", nil)
+	expectStatus(t, w, http.StatusOK)
+	var trash []models.Task
+	decode(t, w, &trash)
+	if len(trash) != 1 || trash[0].ID != task.ID || trash[0].DeletedAt == nil {
+		t.Errorf("trash = %+v", trash)
+	}
+
+	w = a.do(t, aliceToken, "POST", path+"/restore", nil)
+	expectStatus(t, w, http.StatusOK)
+	expectStatus(t, a.do(t, bobToken, "GET", path, nil), http.StatusOK)
+
+	w = a.do(t, aliceToken, "GET", path+"/history", nil)
+	expectStatus(t, w, http.StatusOK)
+	var history []models.TaskHistoryEntry
+	decode(t, w, &history)
+	if n := len(history); n < 2 || history[n-2].Action != models.HistoryDeleted || history[n-1].Action != models.HistoryRestored {
+		t.Errorf("history = %+v, want it to end with the deletion and the restore", history)
+	}
+	want := []string{models.EventTaskCreated, models.EventTaskDeleted, models.EventTaskRestored}
+	if got := a.eventTypes(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestFriendships(t *testing.T) {
+	a := newTestApp(t)
+	alice, aliceToken := a.signUp(t, "alice", defaultRole)
+	bob, bobToken := a.signUp(t, "bob", defaultRole)
+	carol, carolToken := a.signUp(t, "carol", defaultRole)
+
+	// the sender comes from the token, not the body
+	w := a.do(t, aliceToken, "POST", "/friends", models.Friendship{UserID: carol.ID, FriendID: bob.ID, Status: "accepted"})
+	expectStatus(t, w, http.StatusCreated)
+	var request models.Friendship
+	decode(t, w, &request)
+	if request.ID == 0 || request.UserID != alice.ID || request.FriendID != bob.ID || request.Status != "pending" {
+		t.Errorf("request = %+v", request)
+	}
+
+	// only the receiver accepts
+	expectStatus(t, a.do(t, carolToken, "POST", "/friends/accept", models.Friendship{UserID: alice.ID}), http.StatusNotFound)
+	w = a.do(t, bobToken, "POST", "/friends/accept", models.Friendship{UserID: alice.ID})
+	expectStatus(t, w, http.StatusOK)
+	var accepted models.Friendship
+	decode(t, w, &accepted)
+	if accepted.ID != request.ID || accepted.Status != "accepted" {
+		t.Errorf("accepted = %+v", accepted)
+	}
+	expectStatus(t, a.do(t, bobToken, "POST", "/friends/accept", models.Friendship{UserID: alice.ID}), http.StatusNotFound)
+
+	want := []string{models.EventFriendshipRequested, models.EventFriendshipAccepted}
+	if got := a.eventTypes(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	w = a.do(t, carolToken, "POST", "/friends", models.Friendship{FriendID: bob.ID})
+	expectStatus(t, w, http.StatusCreated)
+	w = a.do(t, bobToken, "POST", "/friends/reject", models.Friendship{UserID: carol.ID})
+	expectStatus(t, w, http.StatusOK)
+	if f, err := a.Friendships.GetFriendship(carol.ID, bob.ID); err != nil || f.Status != "rejected" {
+		t.Errorf("friendship = %+v, %v, want rejected", f, err)
+	}
+	if f, _ := a.Friendships.GetFriendship(alice.ID, bob.ID); f.Status != "accepted" {
+		t.Errorf("rejecting carol changed the friendship with alice to %q", f.Status)
+	}
+
+	expectStatus(t, a.do(t, "", "POST", "/friends", models.Friendship{FriendID: bob.ID}), http.StatusUnauthorized)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"time"
+)
+
+// taskFields lists the tracked fields of a task with their values as text.
+func taskFields(task models.Task) [][2]string {
+	parentID := ""
+	if task.ParentID != nil {
+		parentID = strconv.Itoa(*task.ParentID)
+	}
+	return [][2]string{
+		{"title", task.Title},
+		{"description", task.Description},
+		{"status", task.Status},
+		{"priority", task.Priority},
+		{"start_date", task.StartDate.UTC().Format(time.RFC3339)},
+		{"due_date", task.DueDate.UTC().Format(time.RFC3339)},
+		{"assigned_to", strconv.Itoa(task.AssignedTo)},
+		{"parent_id", parentID},
+	}
+}
+
+// GetTaskHistory godoc
+// @Summary Get the change history of a task
+// @Description Get who changed which field of the task and when, oldest first
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} models.TaskHistoryEntry
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/history [get]
+func (db *AppHandler) GetTaskHistory() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		entries, err := db.History.ListTaskHistory(task.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []models.TaskHistoryEntry{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(entries)
+	})
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"task-management-system/models"
+	"task-management-system/store"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultLabelColor = "#808080"
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+func validateLabel(label *models.Label) error {
+	label.Name = strings.TrimSpace(label.Name)
+	if label.Name == "" || len(label.Name) > 50 {
+		return errors.New("label name must be 1 to 50 characters")
+	}
+	if strings.Contains(label.Name, ",") {
+		return errors.New("label name cannot contain a comma")
+	}
+	label.Color = strings.ToLower(label.Color)
+	if label.Color == "" {
+		label.Color = defaultLabelColor
+	}
+	if !labelColorPattern.MatchString(label.Color) {
+		return fmt.Errorf("invalid color %q, expected #rrggbb", label.Color)
+	}
+	return nil
+}
+
+// ownLabel loads the label named by the {label_id} route variable. Labels of
+// other users are reported as not found.
+func (db *AppHandler) ownLabel(r *http.Request) (models.Label, int, error) {
+	labelID, err := strconv.Atoi(mux.Vars(r)["label_id"])
+	if err != nil {
+		return models.Label{}, http.StatusBadRequest, errors.New("Invalid label ID")
+	}
+	label, err := db.Labels.GetLabel(labelID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && label.OwnerID != r.Context().Value("userID").(int)) {
+		return label, http.StatusNotFound, errors.New("Label not found")
+	}
+	if err != nil {
+		return label, http.StatusInternalServerError, err
+	}
+	return label, 0, nil
+}
+
+// labelIDs resolves a comma separated list of label names of the user.
+func (db *AppHandler) labelIDs(userID int, names string) ([]int, error) {
+	if names == "" {
+		return nil, nil
+	}
+	labels, err := db.Labels.ListLabels(userID)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]int{}
+	for _, label := range labels {
+		byName[label.Name] = label.ID
+	}
+	var ids []int
+	for _, name := range strings.Split(names, ",") {
+		id, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, &unknownLabelError{name}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+type unknownLabelError struct{ name string }
+
+func (e *unknownLabelError) Error() string { return fmt.Sprintf("unknown label %q", e.name) }
+
+// ListLabels godoc
+// @Summary List labels
+// @Description Get the labels of the user
+// @Tags labels
+// @Produce  json
+// @Success 200 {array} models.Label
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Router /labels [get]
+func (db *AppHandler) ListLabels() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("userID").(int)
+
+		labels, err := db.Labels.ListLabels(userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if labels == nil {
+			labels = []models.Label{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(labels)
+	})
+}
+
+// CreateLabel godoc
+// @Summary Create a label
+// @Description Create a label. The color defaults to #808080.
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param label body models.Label true "Label"
+// @Success 201 {object} models.Label
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Router /labels [post]
+func (db *AppHandler) CreateLabel() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var label models.Label
+		if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateLabel(&label); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		label.OwnerID = r.Context().Value("userID").(int)
+
+		err := db.Labels.CreateLabel(&label)
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, "Label already exists", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(label)
+	})
+}
+
+// UpdateLabel godoc
+// @Summary Update a label
+// @Description Rename a label or change its color
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param label_id path int true "Label ID"
+// @Param label body models.Label true "Label"
+// @Success 200 {object} models.Label
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Router /labels/{label_id} [put]
+func (db *AppHandler) UpdateLabel() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label, code, err := db.ownLabel(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		var req models.Label
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Name != "" {
+			label.Name = req.Name
+		}
+		if req.Color != "" {
+			label.Color = req.Color
+		}
+		if err := validateLabel(&label); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = db.Labels.UpdateLabel(label)
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, "Label already exists", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(label)
+	})
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description Delete a label and remove it from every task
+// @Tags labels
+// @Param label_id path int true "Label ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /labels/{label_id} [delete]
+func (db *AppHandler) DeleteLabel() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label, code, err := db.ownLabel(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		if err := db.Labels.DeleteLabel(label.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// AddTaskLabel godoc
+// @Summary Label a task
+// @Description Put one of the user's labels on a task the user can see
+// @Tags labels
+// @Param task_id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/labels/{label_id} [put]
+func (db *AppHandler) AddTaskLabel() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		label, code, err := db.ownLabel(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		if err := db.Labels.AddTaskLabel(task.ID, label.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RemoveTaskLabel godoc
+// @Summary Remove a label from a task
+// @Tags labels
+// @Param task_id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/labels/{label_id} [delete]
+func (db *AppHandler) RemoveTaskLabel() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		label, code, err := db.ownLabel(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		err = db.Labels.RemoveTaskLabel(task.ID, label.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Task does not have the label", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
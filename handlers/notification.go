@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// NotifyEvent is subscribed to the event bus. It notifies users of tasks
+// assigned to them by someone else, of comments mentioning them and of
+// friend requests sent to them or accepted. The notifications carry the
+// event ID, so an event published again notifies nobody twice.
+func (db *AppHandler) NotifyEvent(ev models.Event) error {
+	switch ev.Type {
+	case models.EventCommentCreated, models.EventCommentUpdated:
+		return db.notifyMentions(ev)
+	case models.EventTaskCreated, models.EventTaskUpdated:
+		var data models.TaskEvent
+		if err := json.Unmarshal(ev.Data, &data); err != nil {
+			return err
+		}
+		task := data.Task
+		assigned := ev.Type == models.EventTaskCreated
+		for _, c := range data.Changes {
+			assigned = assigned || c.Field == "assigned_to"
+		}
+		if !assigned || task.AssignedTo == 0 || task.AssignedTo == ev.ActorID {
+			return nil
+		}
+		return db.Notifier.Notify(&models.Notification{
+			UserID:    task.AssignedTo,
+			Type:      models.NotificationTaskAssigned,
+			TaskID:    task.ID,
+			ActorID:   ev.ActorID,
+			Message:   fmt.Sprintf("You were assigned task #%d %q", task.ID, task.Title),
+			EventID:   ev.ID,
+			CreatedAt: ev.CreatedAt,
+		})
+	case models.EventFriendshipRequested, models.EventFriendshipAccepted:
+		var f models.Friendship
+		if err := json.Unmarshal(ev.Data, &f); err != nil {
+			return err
+		}
+		actor, err := db.Users.GetUser(ev.ActorID)
+		if err != nil {
+			return err
+		}
+		n := models.Notification{
+			UserID:    f.FriendID,
+			Type:      models.NotificationFriendRequest,
+			ActorID:   ev.ActorID,
+			Message:   fmt.Sprintf("%s sent you a friend request", actor.Username),
+			EventID:   ev.ID,
+			CreatedAt: ev.CreatedAt,
+		}
+		if ev.Type == models.EventFriendshipAccepted {
+			n.UserID, n.Type = f.UserID, models.NotificationFriendAccepted
+			n.Message = fmt.Sprintf("%s accepted your friend request", actor.Username)
+		}
+		if n.UserID == ev.ActorID {
+			return nil
+		}
+		return db.Notifier.Notify(&n)
+	}
+	return nil
+}
+
+// GetNotifications godoc
+// @Summary List notifications
+// @Description Get the notifications of the user, newest first. The X-Unread-Count header holds the number of unread notifications.
+// @Tags notifications
+// @Produce  json
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {array} models.Notification
+// @Header 200 {integer} X-Unread-Count "Number of unread notifications"
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /notifications [get]
+func (db *AppHandler) GetNotifications() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("userID").(int)
+
+		unreadOnly := false
+		if v := r.URL.Query().Get("unread"); v != "" {
+			var err error
+			if unreadOnly, err = strconv.ParseBool(v); err != nil {
+				http.Error(w, "Invalid unread value", http.StatusBadRequest)
+				return
+			}
+		}
+
+		notifications, err := db.Notifications.ListNotifications(userID, unreadOnly)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if notifications == nil {
+			notifications = []models.Notification{}
+		}
+		unread, err := db.Notifications.CountUnreadNotifications(userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("X-Unread-Count", strconv.Itoa(unread))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(notifications)
+	})
+}
+
+func (db *AppHandler) setNotificationRead(read bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notificationID, err := strconv.Atoi(mux.Vars(r)["notification_id"])
+		if err != nil {
+			http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+			return
+		}
+
+		var readAt *time.Time
+		if read {
+			now := time.Now()
+			readAt = &now
+		}
+		err = db.Notifications.SetNotificationRead(notificationID, r.Context().Value("userID").(int), readAt)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Param notification_id path int true "Notification ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /notifications/{notification_id}/read [put]
+func (db *AppHandler) MarkNotificationRead() http.Handler {
+	return db.setNotificationRead(true)
+}
+
+// MarkNotificationUnread godoc
+// @Summary Mark a notification as unread
+// @Tags notifications
+// @Param notification_id path int true "Notification ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /notifications/{notification_id}/read [delete]
+func (db *AppHandler) MarkNotificationUnread() http.Handler {
+	return db.setNotificationRead(false)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all notifications as read
+// @Tags notifications
+// @Success 204
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /notifications/read [post]
+func (db *AppHandler) MarkAllNotificationsRead() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := db.Notifications.MarkAllNotificationsRead(r.Context().Value("userID").(int), time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetNotificationPreferences godoc
+// @Summary Get notification preferences
+// @Description Get the channels (in_app, email) each type of notification is delivered over
+// @Tags notifications
+// @Produce  json
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /user/notification-preferences [get]
+func (db *AppHandler) GetNotificationPreferences() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefs, err := db.Notifier.Preferences(r.Context().Value("userID").(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(prefs)
+	})
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary Update notification preferences
+// @Description Choose the channels (in_app, email) per notification type. An empty list turns a type off, types left out go back to the server defaults. Emails are only sent when the server has SMTP configured and the user has an email address.
+// @Tags notifications
+// @Accept  json
+// @Produce  json
+// @Param preferences body models.NotificationPreferences true "Notification preferences"
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /user/notification-preferences [put]
+func (db *AppHandler) UpdateNotificationPreferences() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var prefs models.NotificationPreferences
+		if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		channels := map[string][]string{}
+		for t, list := range prefs.Channels {
+			if !models.IsNotificationType(t) {
+				http.Error(w, fmt.Sprintf("unknown notification type %q", t), http.StatusBadRequest)
+				return
+			}
+			seen := map[string]bool{}
+			channels[t] = []string{}
+			for _, c := range list {
+				if !models.IsNotificationChannel(c) {
+					http.Error(w, fmt.Sprintf("unknown channel %q, expected in_app or email", c), http.StatusBadRequest)
+					return
+				}
+				if !seen[c] {
+					seen[c] = true
+					channels[t] = append(channels[t], c)
+				}
+			}
+		}
+
+		userID := r.Context().Value("userID").(int)
+		if err := db.Notifications.SetNotificationPreferences(userID, channels); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		prefs, err := db.Notifier.Preferences(userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(prefs)
+	})
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"task-management-system/models"
+	"task-management-system/recurrence"
+	"task-management-system/store"
+	"time"
+)
+
+// GenerateRecurringTasks creates the next occurrence of every recurring task
+// whose latest occurrence is closed or whose next start has arrived. A latest
+// occurrence that was deleted, or is in the trash, does not count as closed:
+// the series waits for its next start. When several starts have passed, e.g.
+// after downtime, only the latest one is created. It returns the number of
+// tasks created.
+func (db *AppHandler) GenerateRecurringTasks(now time.Time) (int, error) {
+	recs, err := db.Recurrences.ListActiveRecurrences()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	var errs []error
+	for _, rec := range recs {
+		ok, err := db.generateOccurrence(rec, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %d: %w", rec.TaskID, err))
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+func (db *AppHandler) generateOccurrence(rec models.TaskRecurrence, now time.Time) (bool, error) {
+	template, err := db.Tasks.GetTask(rec.TaskID)
+	if errors.Is(err, store.ErrNotFound) {
+		// şablon silinmiş, seri de biter
+		return false, db.Recurrences.DeleteRecurrence(rec.TaskID)
+	}
+	if err != nil {
+		return false, err
+	}
+	if template.DeletedAt != nil {
+		return false, nil
+	}
+
+	rule, err := recurrence.Parse(rec.Rule)
+	if err != nil {
+		return false, err
+	}
+	loc, err := time.LoadLocation(rec.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	if rec.NextStart.After(now) {
+		last, err := db.Tasks.GetTask(rec.LastTaskID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return false, err
+		}
+		if err != nil || last.DeletedAt != nil || !db.Workflow.IsTerminal(last.Status) {
+			return false, nil
+		}
+	}
+
+	start := *rec.NextStart
+	for {
+		next, ok := rule.Next(rec.Start, loc, start)
+		if !ok || next.After(now) {
+			break
+		}
+		start = next
+	}
+
+	task := models.Task{
+		Title:       template.Title,
+		Description: template.Description,
+		Status:      db.Workflow.Initial,
+		Priority:    template.Priority,
+		StartDate:   start.UTC(),
+		UserID:      template.UserID,
+		AssignedTo:  template.AssignedTo,
+		ParentID:    template.ParentID,
+	}
+	if !template.DueDate.IsZero() {
+		task.DueDate = start.Add(template.DueDate.Sub(template.StartDate))
+	}
+
+	var nextStart *time.Time
+	if next, ok := rule.Next(rec.Start, loc, start); ok {
+		next = next.UTC()
+		nextStart = &next
+	}
+	if err := db.Recurrences.CreateOccurrence(rec.TaskID, &task, nextStart, taskEvent(template.UserID, nil, &task)); err != nil {
+		return false, err
+	}
+	db.Events.Wake()
+	return true, nil
+}
+
+// GetRecurrence godoc
+// @Summary Get the recurrence of a task
+// @Description Get the rule a task repeats with and the start of its next occurrence
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {object} models.TaskRecurrence
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/recurrence [get]
+func (db *AppHandler) GetRecurrence() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		rec, err := db.Recurrences.GetRecurrence(task.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Task does not repeat", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rec)
+	})
+}
+
+// SetRecurrence godoc
+// @Summary Repeat a task
+// @Description Make the task the template of a recurring series, or change its rule. The rule is an RFC 5545 RRULE with FREQ DAILY, WEEKLY or MONTHLY and INTERVAL, BYDAY, BYMONTHDAY, COUNT, UNTIL; daily, weekly and monthly are accepted as shortcuts. The series starts at the start_date of the task and keeps its local time in the given time zone (default UTC). The next occurrence is created when the latest one is completed or cancelled, or when its start date arrives.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param recurrence body models.RecurrenceRequest true "Recurrence rule"
+// @Success 200 {object} models.TaskRecurrence
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/recurrence [put]
+func (db *AppHandler) SetRecurrence() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		if task.StartDate.IsZero() {
+			http.Error(w, "The task needs a start_date to repeat", http.StatusBadRequest)
+			return
+		}
+
+		var req models.RecurrenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rule, err := recurrence.Parse(req.Rule)
+		if err != nil {
+			http.Error(w, "invalid rule: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Timezone == "" {
+			req.Timezone = "UTC"
+		}
+		loc, err := time.LoadLocation(req.Timezone)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("unknown timezone %q", req.Timezone), http.StatusBadRequest)
+			return
+		}
+
+		rec, err := db.Recurrences.GetRecurrence(task.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			rec = models.TaskRecurrence{TaskID: task.ID, LastTaskID: task.ID, CreatedAt: time.Now()}
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rec.Rule, rec.Timezone, rec.Start = rule.String(), loc.String(), task.StartDate
+
+		// yeni kural, seride zaten oluşturulmuş son görevden sonra devam eder
+		lastStart := task.StartDate
+		if rec.LastTaskID != task.ID {
+			last, err := db.Tasks.GetTask(rec.LastTaskID)
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err == nil && last.StartDate.After(lastStart) {
+				lastStart = last.StartDate
+			}
+		}
+		rec.NextStart = nil
+		if next, ok := rule.Next(rec.Start, loc, lastStart); ok {
+			next = next.UTC()
+			rec.NextStart = &next
+		}
+
+		if err := db.Recurrences.SetRecurrence(rec); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rec)
+	})
+}
+
+// DeleteRecurrence godoc
+// @Summary Stop repeating a task
+// @Description Remove the recurrence of a task. Occurrences created so far are kept.
+// @Tags tasks
+// @Param task_id path int true "Task ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/recurrence [delete]
+func (db *AppHandler) DeleteRecurrence() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		err := db.Recurrences.DeleteRecurrence(task.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Task does not repeat", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
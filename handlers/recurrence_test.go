@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"task-management-system/models"
+	"task-management-system/recurrence"
+	"task-management-system/store"
+	"testing"
+	"time"
+)
+
+// repeat makes a new task starting at start the template of a series, the
+// way SetRecurrence does.
+func (a *testApp) repeat(t *testing.T, rule, timezone string, start time.Time) models.Task {
+	t.Helper()
+	if a.Recurrences == nil {
+		recurrences := store.NewMemoryRecurrenceStore()
+		recurrences.Tasks = a.Tasks.(*store.MemoryTaskStore)
+		a.Recurrences = recurrences
+	}
+	template := models.Task{Title: "standup", Status: "pending", Priority: models.PriorityMedium, StartDate: start.UTC(),
+		DueDate: start.Add(2 * time.Hour).UTC(), UserID: 1, AssignedTo: 1}
+	if err := a.Tasks.CreateTask(&template, nil); err != nil {
+		t.Fatal(err)
+	}
+	r, err := recurrence.Parse(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := models.TaskRecurrence{TaskID: template.ID, Rule: r.String(), Timezone: timezone, Start: template.StartDate, LastTaskID: template.ID}
+	if next, ok := r.Next(rec.Start, loc, rec.Start); ok {
+		next = next.UTC()
+		rec.NextStart = &next
+	}
+	if err := a.Recurrences.SetRecurrence(rec); err != nil {
+		t.Fatal(err)
+	}
+	return template
+}
+
+// generate runs GenerateRecurringTasks at now and returns the recurrence of
+// the template and its latest occurrence.
+func (a *testApp) generate(t *testing.T, template models.Task, now time.Time, wantCreated int) (models.TaskRecurrence, models.Task) {
+	t.Helper()
+	created, err := a.GenerateRecurringTasks(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != wantCreated {
+		t.Errorf("at %s created %d occurrences, want %d", now.Format(time.RFC3339), created, wantCreated)
+	}
+	rec, err := a.Recurrences.GetRecurrence(template.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := a.Tasks.GetTask(rec.LastTaskID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		t.Fatal(err)
+	}
+	return rec, last
+}
+
+var monday = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+func TestGenerateWaitsForOpenOccurrence(t *testing.T) {
+	a := newTestApp(t)
+	template := a.repeat(t, "FREQ=DAILY", "UTC", monday)
+
+	rec, _ := a.generate(t, template, monday.Add(23*time.Hour), 0)
+	if rec.LastTaskID != template.ID {
+		t.Errorf("the series moved on to task %d while the template is open", rec.LastTaskID)
+	}
+
+	// once closed, the next occurrence is created before its start
+	template.Status = "completed"
+	if err := a.Tasks.UpdateTask(template, nil); err != nil {
+		t.Fatal(err)
+	}
+	rec, last := a.generate(t, template, monday.Add(time.Hour), 1)
+	if want := monday.AddDate(0, 0, 1); !last.StartDate.Equal(want) || !last.DueDate.Equal(want.Add(2*time.Hour)) {
+		t.Errorf("occurrence %s - %s, want it to start at %s and keep the 2h due offset", last.StartDate, last.DueDate, want)
+	}
+	if last.Status != a.Workflow.Initial || last.Title != template.Title {
+		t.Errorf("occurrence = %+v", last)
+	}
+	if want := monday.AddDate(0, 0, 2); rec.NextStart == nil || !rec.NextStart.Equal(want) {
+		t.Errorf("NextStart = %v, want %s", rec.NextStart, want)
+	}
+}
+
+func TestGenerateAfterDeletedOccurrence(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete func(t *testing.T, a *testApp, last models.Task)
+	}{
+		{"trashed", func(t *testing.T, a *testApp, last models.Task) {
+			if err := a.Tasks.TrashTask(last.ID, 1, monday, nil); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"purged", func(t *testing.T, a *testApp, last models.Task) {
+			if err := a.Tasks.TrashTask(last.ID, 1, monday, nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := a.Tasks.PurgeTasks(monday.Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t)
+			template := a.repeat(t, "FREQ=DAILY", "UTC", monday)
+			_, last := a.generate(t, template, monday.AddDate(0, 0, 1), 1)
+			tt.delete(t, a, last)
+
+			// a deleted occurrence is not closed, the series stalls until
+			// the next start instead of creating the next one right away
+			rec, _ := a.generate(t, template, monday.AddDate(0, 0, 2).Add(-time.Minute), 0)
+			if rec.LastTaskID != last.ID {
+				t.Fatalf("the series moved on to task %d", rec.LastTaskID)
+			}
+			_, next := a.generate(t, template, monday.AddDate(0, 0, 2), 1)
+			if want := monday.AddDate(0, 0, 2); !next.StartDate.Equal(want) {
+				t.Errorf("occurrence starts at %s, want %s", next.StartDate, want)
+			}
+		})
+	}
+}
+
+func TestGenerateCatchesUpToLatestStart(t *testing.T) {
+	a := newTestApp(t)
+	template := a.repeat(t, "FREQ=DAILY", "UTC", monday)
+
+	// after downtime only the latest passed start is created
+	rec, last := a.generate(t, template, monday.AddDate(0, 0, 3).Add(time.Hour), 1)
+	if want := monday.AddDate(0, 0, 3); !last.StartDate.Equal(want) {
+		t.Errorf("occurrence starts at %s, want %s", last.StartDate, want)
+	}
+	if want := monday.AddDate(0, 0, 4); rec.NextStart == nil || !rec.NextStart.Equal(want) {
+		t.Errorf("NextStart = %v, want %s", rec.NextStart, want)
+	}
+}
+
+func TestGenerateEndsSeries(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{"count", "FREQ=DAILY;COUNT=2"},
+		{"until", "FREQ=DAILY;UNTIL=20260303T090000Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t)
+			template := a.repeat(t, tt.rule, "UTC", monday)
+
+			// the second occurrence is the last one of the rule
+			rec, last := a.generate(t, template, monday.AddDate(0, 0, 1), 1)
+			if !last.StartDate.Equal(monday.AddDate(0, 0, 1)) {
+				t.Errorf("occurrence starts at %s", last.StartDate)
+			}
+			if rec.NextStart != nil {
+				t.Fatalf("NextStart = %s, want the series ended", rec.NextStart)
+			}
+
+			last.Status = "completed"
+			if err := a.Tasks.UpdateTask(last, nil); err != nil {
+				t.Fatal(err)
+			}
+			if rec, _ = a.generate(t, template, monday.AddDate(0, 0, 5), 0); rec.LastTaskID != last.ID {
+				t.Errorf("an ended series created task %d", rec.LastTaskID)
+			}
+		})
+	}
+}
+
+func TestGenerateKeepsLocalTimeAcrossDST(t *testing.T) {
+	a := newTestApp(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	// clocks go forward on 2026-03-29 in Berlin, 09:00 moves from 08:00 to
+	// 07:00 UTC
+	template := a.repeat(t, "FREQ=DAILY", "Europe/Berlin", time.Date(2026, 3, 28, 9, 0, 0, 0, berlin))
+
+	rec, last := a.generate(t, template, time.Date(2026, 3, 29, 7, 0, 0, 0, time.UTC), 1)
+	if want := time.Date(2026, 3, 29, 9, 0, 0, 0, berlin); !last.StartDate.Equal(want) {
+		t.Errorf("occurrence starts at %s, want %s", last.StartDate.In(berlin), want)
+	}
+	if !last.DueDate.Equal(last.StartDate.Add(2 * time.Hour)) {
+		t.Errorf("due %s, want 2h after the start", last.DueDate.In(berlin))
+	}
+	if want := time.Date(2026, 3, 30, 9, 0, 0, 0, berlin); rec.NextStart == nil || !rec.NextStart.Equal(want) {
+		t.Errorf("NextStart = %v, want %s", rec.NextStart, want)
+	}
+}
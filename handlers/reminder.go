@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+)
+
+const (
+	reminderOverdue    = "overdue"
+	reminderEscalation = "escalation"
+	maxReminderTimes   = 5
+)
+
+// reminderPreferences returns the saved preferences of the user, or the
+// defaults when there are none.
+func (db *AppHandler) reminderPreferences(userID int) (models.ReminderPreferences, error) {
+	prefs, err := db.Reminders.GetReminderPreferences(userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.ReminderPreferences{UserID: userID, Enabled: true, LeadHours: db.ReminderLeadHours, Escalations: true}, nil
+	}
+	return prefs, err
+}
+
+// humanDuration rounds d to whole days, hours or minutes for messages.
+func humanDuration(d time.Duration) string {
+	unit, name := time.Minute, "minute"
+	switch {
+	case d >= 48*time.Hour:
+		unit, name = 24*time.Hour, "day"
+	case d >= 2*time.Hour:
+		unit, name = time.Hour, "hour"
+	}
+	n := int((d + unit/2) / unit)
+	if n == 1 {
+		return "1 " + name
+	}
+	return fmt.Sprintf("%d %ss", n, name)
+}
+
+// validateReminderPreferences removes duplicate lead hours and sorts them,
+// latest reminder last.
+func validateReminderPreferences(prefs *models.ReminderPreferences) error {
+	if len(prefs.LeadHours) > maxReminderTimes {
+		return fmt.Errorf("at most %d lead_hours are allowed", maxReminderTimes)
+	}
+	seen := map[int]bool{}
+	var hours []int
+	for _, h := range prefs.LeadHours {
+		if h < 1 || h > models.MaxReminderLeadHours {
+			return fmt.Errorf("lead_hours must be between 1 and %d", models.MaxReminderLeadHours)
+		}
+		if !seen[h] {
+			seen[h] = true
+			hours = append(hours, h)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(hours)))
+	prefs.LeadHours = hours
+	return nil
+}
+
+// SendReminders notifies assignees of open tasks that are due soon or overdue
+// and escalates tasks overdue for longer than the grace period to their
+// creators. Every reminder is claimed before it is sent, so it is sent once
+// per due date even across restarts, and released when sending fails so the
+// next run tries again. A failing task does not stop the others. It returns
+// the number of notifications created.
+func (db *AppHandler) SendReminders(now time.Time) (int, error) {
+	q := store.TaskQuery{
+		Statuses: db.Workflow.OpenStatuses(),
+		// sıfır bitiş tarihi "tarih yok" demek
+		DueFrom: time.Date(1, 1, 2, 0, 0, 0, 0, time.UTC),
+		DueTo:   now.Add(models.MaxReminderLeadHours * time.Hour),
+		Limit:   store.MaxTaskLimit,
+	}
+	prefs := map[int]models.ReminderPreferences{}
+	sent := 0
+	var errs []error
+	for {
+		page, err := db.Tasks.ListTasks(q)
+		if err != nil {
+			return sent, errors.Join(append(errs, err)...)
+		}
+		for _, task := range page.Tasks {
+			n, err := db.remindTask(task, now, prefs)
+			sent += n
+			if err != nil {
+				errs = append(errs, fmt.Errorf("task %d: %w", task.ID, err))
+			}
+		}
+		if page.NextCursor == "" {
+			return sent, errors.Join(errs...)
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func (db *AppHandler) remindTask(task models.Task, now time.Time, cache map[int]models.ReminderPreferences) (int, error) {
+	prefsOf := func(userID int) (models.ReminderPreferences, error) {
+		if prefs, ok := cache[userID]; ok {
+			return prefs, nil
+		}
+		prefs, err := db.reminderPreferences(userID)
+		if err == nil {
+			cache[userID] = prefs
+		}
+		return prefs, err
+	}
+
+	sent := 0
+	send := func(userID int, kind, notificationType string, actorID int, message string) error {
+		delivery := models.ReminderDelivery{TaskID: task.ID, UserID: userID, Kind: kind, DueDate: task.DueDate, CreatedAt: now}
+		err := db.Reminders.ClaimReminder(delivery)
+		if errors.Is(err, store.ErrConflict) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = db.Notifier.Notify(&models.Notification{
+			UserID:    userID,
+			Type:      notificationType,
+			TaskID:    task.ID,
+			ActorID:   actorID,
+			Message:   message,
+			CreatedAt: now,
+		})
+		if err != nil {
+			// gönderilemedi, sonraki çalışmada yeniden denensin
+			if rerr := db.Reminders.ReleaseReminder(delivery); rerr != nil {
+				return errors.Join(err, rerr)
+			}
+			return err
+		}
+		sent++
+		return nil
+	}
+
+	var assignee models.ReminderPreferences
+	if task.AssignedTo != 0 {
+		var err error
+		if assignee, err = prefsOf(task.AssignedTo); err != nil {
+			return sent, err
+		}
+	}
+
+	if now.Before(task.DueDate) {
+		if task.AssignedTo == 0 || !assignee.Enabled {
+			return sent, nil
+		}
+		// yalnızca geçilen en yakın eşik için hatırlatılır
+		lead := 0
+		for _, h := range assignee.LeadHours {
+			if !now.Before(task.DueDate.Add(-time.Duration(h)*time.Hour)) && (lead == 0 || h < lead) {
+				lead = h
+			}
+		}
+		if lead == 0 {
+			return sent, nil
+		}
+		err := send(task.AssignedTo, fmt.Sprintf("due_in_%dh", lead), models.NotificationReminder, 0,
+			fmt.Sprintf("Task #%d %q is due in %s", task.ID, task.Title, humanDuration(task.DueDate.Sub(now))))
+		return sent, err
+	}
+
+	if task.OverdueAt == nil {
+		if err := db.Tasks.MarkTaskOverdue(task.ID, now); err != nil {
+			return sent, err
+		}
+	}
+	if task.AssignedTo != 0 && assignee.Enabled {
+		err := send(task.AssignedTo, reminderOverdue, models.NotificationOverdue, 0,
+			fmt.Sprintf("Task #%d %q is overdue, it was due %s", task.ID, task.Title, task.DueDate.UTC().Format(time.RFC3339)))
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	if now.Before(task.DueDate.Add(db.EscalationGrace)) || task.UserID == task.AssignedTo {
+		return sent, nil
+	}
+	creator, err := prefsOf(task.UserID)
+	if err != nil || !creator.Escalations {
+		return sent, err
+	}
+	err = send(task.UserID, reminderEscalation, models.NotificationEscalation, task.AssignedTo,
+		fmt.Sprintf("Task #%d %q is still open %s after its due date", task.ID, task.Title, humanDuration(now.Sub(task.DueDate))))
+	return sent, err
+}
+
+// GetReminderPreferences godoc
+// @Summary Get reminder preferences
+// @Description Get when the user is reminded of the due dates of their tasks
+// @Tags reminders
+// @Produce  json
+// @Success 200 {object} models.ReminderPreferences
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /user/reminder-preferences [get]
+func (db *AppHandler) GetReminderPreferences() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefs, err := db.reminderPreferences(r.Context().Value("userID").(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if prefs.LeadHours == nil {
+			prefs.LeadHours = []int{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(prefs)
+	})
+}
+
+// UpdateReminderPreferences godoc
+// @Summary Update reminder preferences
+// @Description Choose how many hours before the due date to be reminded (up to 5 times, at most 720 hours), whether to be notified of tasks assigned to the user at all, and whether to be notified when tasks the user created stay overdue.
+// @Tags reminders
+// @Accept  json
+// @Produce  json
+// @Param preferences body models.ReminderPreferences true "Reminder preferences"
+// @Success 200 {object} models.ReminderPreferences
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /user/reminder-preferences [put]
+func (db *AppHandler) UpdateReminderPreferences() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var prefs models.ReminderPreferences
+		if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateReminderPreferences(&prefs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		prefs.UserID = r.Context().Value("userID").(int)
+
+		if err := db.Reminders.SetReminderPreferences(prefs); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if prefs.LeadHours == nil {
+			prefs.LeadHours = []int{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(prefs)
+	})
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// built-in roles cannot be deleted
+var builtinRoles = map[string]bool{"admin": true, "user": true}
+
+// defaultRole is given to every registered user.
+const defaultRole = "user"
+
+func validatePermissions(permissions []string) error {
+	for _, p := range permissions {
+		if !models.IsPermission(p) {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	return nil
+}
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Description List every permission that can be granted to a role
+// @Tags roles
+// @Produce  json
+// @Success 200 {array} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Router /permissions [get]
+func (db *AppHandler) ListPermissions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(models.Permissions)
+	})
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List roles with their permissions
+// @Tags roles
+// @Produce  json
+// @Success 200 {array} models.Role
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Router /roles [get]
+func (db *AppHandler) ListRoles() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roles, err := db.Roles.ListRoles()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(roles)
+	})
+}
+
+// CreateRole godoc
+// @Summary Create a role
+// @Description Define a custom role as a set of permissions
+// @Tags roles
+// @Accept  json
+// @Produce  json
+// @Param role body models.Role true "Role info"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Router /roles [post]
+func (db *AppHandler) CreateRole() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var role models.Role
+		if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if role.Name == "" {
+			http.Error(w, "Role name is required", http.StatusBadRequest)
+			return
+		}
+		if err := validatePermissions(role.Permissions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if role.Permissions == nil {
+			role.Permissions = []string{}
+		}
+
+		err := db.Roles.CreateRole(role)
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, "Role already exists", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(role)
+	})
+}
+
+// UpdateRole godoc
+// @Summary Update role permissions
+// @Description Replace the permissions of a role
+// @Tags roles
+// @Accept  json
+// @Produce  json
+// @Param name path string true "Role name"
+// @Param role body models.Role true "Role info, only permissions are used"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /roles/{name} [put]
+func (db *AppHandler) UpdateRole() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+
+		var role models.Role
+		if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validatePermissions(role.Permissions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err := db.Roles.SetRolePermissions(name, role.Permissions)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Role not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		updated, err := db.Roles.GetRole(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(updated)
+	})
+}
+
+// DeleteRole godoc
+// @Summary Delete a role
+// @Description Delete a custom role that is not assigned to any user
+// @Tags roles
+// @Param name path string true "Role name"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Router /roles/{name} [delete]
+func (db *AppHandler) DeleteRole() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		if builtinRoles[name] {
+			http.Error(w, "Built-in roles cannot be deleted", http.StatusBadRequest)
+			return
+		}
+
+		err := db.Roles.DeleteRole(name)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Role not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, "Role is still assigned to users", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// SetUserRole godoc
+// @Summary Change the role of a user
+// @Description Give a user another role. The access tokens of the user are revoked so the new role applies right away; refreshing picks it up.
+// @Tags roles
+// @Accept  json
+// @Produce  json
+// @Param user_id path int true "User ID"
+// @Param role body models.RoleAssignment true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /users/{user_id}/role [put]
+func (db *AppHandler) SetUserRole() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		var req models.RoleAssignment
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := db.Roles.GetRole(req.Role); errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Unknown role", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = db.Users.SetUserRole(userID, req.Role)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// eski rol erişim belirteçlerinde yazılı
+		if err := db.Revocations.RevokeUserSessions(userID, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		user, err := db.Users.GetUser(userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		user.Password = ""
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(user)
+	})
+}
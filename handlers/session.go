@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the access token used for this request. When a refresh token is sent, its whole family is revoked as well.
+// @Tags auth
+// @Accept  json
+// @Param refresh body models.RefreshRequest false "Refresh token to revoke"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /logout [post]
+func (db *AppHandler) Logout() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value("claims").(*models.Claims)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req models.RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := db.Revocations.RevokeToken(claims.Id, claims.UserID, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if req.RefreshToken != "" {
+			stored, err := db.RefreshTokens.GetRefreshTokenByHash(hashToken(req.RefreshToken))
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// başka bir kullanıcının token'ı ise sessizce yok say
+			if err == nil && stored.UserID == claims.UserID {
+				if err := db.RefreshTokens.RevokeRefreshTokenFamily(stored.FamilyID, time.Now()); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RevokeUserSessions godoc
+// @Summary Revoke all sessions of a user
+// @Description Invalidate every access token issued to the user so far and revoke all of their refresh tokens
+// @Tags auth
+// @Param user_id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /users/{user_id}/sessions/revoke [post]
+func (db *AppHandler) RevokeUserSessions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := db.Users.GetUser(userID); errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		if err := db.Revocations.RevokeUserSessions(userID, now); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := db.RefreshTokens.RevokeUserRefreshTokens(userID, now); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
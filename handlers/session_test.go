@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"testing"
+)
+
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name string
+		// body returns the refresh token sent to /logout, nil for no body.
+		body func(alice, bob models.TokenResponse) interface{}
+		want int
+		// aliceSession and bobSession tell whether the refresh tokens
+		// still work afterwards.
+		aliceSession, bobSession bool
+	}{
+		{
+			name:         "access token only",
+			body:         func(alice, bob models.TokenResponse) interface{} { return nil },
+			want:         http.StatusNoContent,
+			aliceSession: true, bobSession: true,
+		},
+		{
+			name: "with the refresh token",
+			body: func(alice, bob models.TokenResponse) interface{} {
+				return models.RefreshRequest{RefreshToken: alice.RefreshToken}
+			},
+			want:         http.StatusNoContent,
+			aliceSession: false, bobSession: true,
+		},
+		{
+			name: "with the refresh token of another user",
+			body: func(alice, bob models.TokenResponse) interface{} {
+				return models.RefreshRequest{RefreshToken: bob.RefreshToken}
+			},
+			want:         http.StatusNoContent,
+			aliceSession: true, bobSession: true,
+		},
+		{
+			name: "with an unknown refresh token",
+			body: func(alice, bob models.TokenResponse) interface{} {
+				return models.RefreshRequest{RefreshToken: "unknown"}
+			},
+			want:         http.StatusNoContent,
+			aliceSession: true, bobSession: true,
+		},
+		{
+			name:         "malformed body",
+			body:         func(alice, bob models.TokenResponse) interface{} { return "not an object" },
+			want:         http.StatusBadRequest,
+			aliceSession: true, bobSession: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t)
+			_, alice := a.signUpTokens(t, "alice", defaultRole)
+			_, bob := a.signUpTokens(t, "bob", defaultRole)
+
+			expectStatus(t, a.do(t, alice.Token, "POST", "/logout", tt.body(alice, bob)), tt.want)
+
+			wantAccess := http.StatusUnauthorized
+			if tt.want != http.StatusNoContent {
+				wantAccess = http.StatusOK
+			}
+			expectStatus(t, a.do(t, alice.Token, "GET", "/tasks", nil), wantAccess)
+			expectStatus(t, a.do(t, bob.Token, "GET", "/tasks", nil), http.StatusOK)
+
+			for name, session := range map[string]struct {
+				token string
+				live  bool
+			}{"alice": {alice.RefreshToken, tt.aliceSession}, "bob": {bob.RefreshToken, tt.bobSession}} {
+				res, _ := a.refresh(t, session.token)
+				if live := res.StatusCode == http.StatusOK; live != session.live {
+					t.Errorf("refresh token of %s works = %v, want %v", name, live, session.live)
+				}
+			}
+		})
+	}
+
+	a := newTestApp(t)
+	expectStatus(t, a.do(t, "", "POST", "/logout", nil), http.StatusUnauthorized)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	a := newTestApp(t)
+	_, admin := a.signUpTokens(t, "admin", "admin")
+	bob, bobTokens := a.signUpTokens(t, "bob", defaultRole)
+	_, carol := a.signUpTokens(t, "carol", defaultRole)
+	path := "/users/" + strconv.Itoa(bob.ID) + "/sessions/revoke"
+
+	expectStatus(t, a.do(t, carol.Token, "POST", path, nil), http.StatusForbidden)
+	expectStatus(t, a.do(t, admin.Token, "POST", "/users/999/sessions/revoke", nil), http.StatusNotFound)
+	expectStatus(t, a.do(t, admin.Token, "POST", "/users/x/sessions/revoke", nil), http.StatusBadRequest)
+
+	expectStatus(t, a.do(t, admin.Token, "POST", path, nil), http.StatusNoContent)
+	expectStatus(t, a.do(t, bobTokens.Token, "GET", "/tasks", nil), http.StatusUnauthorized)
+	if res, _ := a.refresh(t, bobTokens.RefreshToken); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("refresh after the revocation = %d, want 401", res.StatusCode)
+	}
+	// other users keep their sessions
+	expectStatus(t, a.do(t, carol.Token, "GET", "/tasks", nil), http.StatusOK)
+	if res, _ := a.refresh(t, carol.RefreshToken); res.StatusCode != http.StatusOK {
+		t.Errorf("refresh of another user = %d, want 200", res.StatusCode)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"task-management-system/models"
 )
 
@@ -12,7 +13,9 @@ import (
 // @Tags stats
 // @Accept  json
 // @Produce  json
+// @Param leaf_only query bool false "Only count tasks without subtasks"
 // @Success 200 {object} models.UserStats
+// @Failure 400 {object} string
 // @Failure 401 {object} string
 // @Failure 500 {object} string
 // @Router /user/stats [get]
@@ -27,27 +30,61 @@ func (db *AppHandler) GetStats() http.Handler {
 		userID := userIDValue.(int)
 
 		var stats models.UserStats
+		stats.UserID = userID
 
-		//toplam görev sayısı
-		err := db.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE assigned_to = ?", userID).Scan(&stats.TotalTasks)
+		leafOnly := false
+		if v := r.URL.Query().Get("leaf_only"); v != "" {
+			var err error
+			if leafOnly, err = strconv.ParseBool(v); err != nil {
+				http.Error(w, "invalid leaf_only", http.StatusBadRequest)
+				return
+			}
+		}
+
+		counts, err := db.Tasks.CountTasksByStatus(userID, leafOnly)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		//tamamlanan görev sayısı
-		err = db.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE assigned_to = ? AND status = 'completed'", userID).Scan(&stats.CompletedTasks)
+		stats.ByStatus = map[string]int{}
+		for _, status := range db.Workflow.Statuses() {
+			stats.ByStatus[status] = 0
+		}
+		for status, count := range counts {
+			//toplam görev sayısı, iş akışında olmayan durumlar da dahil
+			stats.TotalTasks += count
+			stats.ByStatus[status] = count
+		}
+		labels, err := db.Labels.ListLabels(userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		byLabel, err := db.Tasks.CountTasksByLabel(userID, leafOnly)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		stats.ByLabel = map[string]int{}
+		for _, label := range labels {
+			stats.ByLabel[label.Name] = byLabel[label.ID]
+		}
 
-		// Bekleyen görev sayısı
-		err = db.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE assigned_to = ? AND status = 'pending'", userID).Scan(&stats.PendingTasks)
+		byPriority, err := db.Tasks.CountTasksByPriority(userID, db.Workflow.OpenStatuses(), leafOnly)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		stats.OpenByPriority = map[string]int{}
+		for _, priority := range models.Priorities {
+			stats.OpenByPriority[priority] = byPriority[priority]
+		}
+
+		for _, status := range db.Workflow.Done {
+			stats.CompletedTasks += counts[status]
+		}
+		stats.PendingTasks = counts[db.Workflow.Initial]
 
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(stats)
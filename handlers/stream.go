@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"time"
+)
+
+// maxStreamReplay is the number of events read from the outbox for a client
+// that missed more than the hub keeps. Clients further behind resync.
+const maxStreamReplay = 1000
+
+// writeEvent writes ev in the text/event-stream format. Its JSON encoding
+// has no newlines, so it fits in a single data line.
+func writeEvent(w http.ResponseWriter, ev models.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Seq, ev.Type, data)
+	return err
+}
+
+// StreamEvents godoc
+// @Summary Stream events
+// @Description Server-Sent Events stream of the task, comment and friendship events the user can see: task.created, task.updated, task.deleted, task.restored, comment.created, comment.updated, comment.deleted, friendship.requested and friendship.accepted. Every message carries the sequence number of the event (its seq field) as the message ID, the event type and the event as JSON. Browsers may authenticate with the token cookie set at login instead of the Authorization header. Clients resume after a reconnect with the Last-Event-ID header (sent by EventSource automatically) or the last_event_id parameter; missed events are replayed from memory or, e.g. after a restart, from the outbox. When they are no longer available (purged after events.retention, or more than 1000) a "resync" event is sent first and the client should reload its data. The stream ends when the access token expires, reconnect with a fresh one.
+// @Tags events
+// @Produce  text/event-stream
+// @Param Last-Event-ID header int false "Sequence number of the last event received"
+// @Param last_event_id query int false "Sequence number of the last event received, for clients that cannot set headers"
+// @Success 200 {object} models.Event
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /events [get]
+func (db *AppHandler) StreamEvents() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		var lastSeq int64
+		v := r.Header.Get("Last-Event-ID")
+		if v == "" {
+			v = r.URL.Query().Get("last_event_id")
+		}
+		if v != "" {
+			var err error
+			if lastSeq, err = strconv.ParseInt(v, 10, 64); err != nil || lastSeq < 0 {
+				http.Error(w, "Invalid last event ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		user, err := db.Users.GetUser(r.Context().Value("userID").(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		actor, err := db.actorFor(user)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		client, missed, complete := db.Stream.Subscribe(lastSeq)
+		defer db.Stream.Unsubscribe(client)
+		if !complete {
+			stored, err := db.Outbox.ListPublishedEvents(lastSeq, maxStreamReplay+1)
+			if err != nil {
+				log.Println("Error reading events: ", err)
+			} else if len(stored) <= maxStreamReplay {
+				// bellekteki olaylar outbox'takilerin devamı
+				for _, ev := range missed {
+					if len(stored) == 0 || ev.Seq > stored[len(stored)-1].Seq {
+						stored = append(stored, ev)
+					}
+				}
+				// sequence numbers have no gaps, a missing one was purged
+				missed, complete = stored, len(stored) == 0 || stored[0].Seq == lastSeq+1
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		// ters vekil sunucular akışı tamponlamasın
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if !complete {
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+		// olaylar sıra numarasıyla gelir, yeniden gönderilenler atlanır
+		sent := lastSeq
+		for _, ev := range missed {
+			sent = ev.Seq
+			if canSeeEvent(actor, ev) {
+				if err := writeEvent(w, ev); err != nil {
+					return
+				}
+			}
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(db.StreamHeartbeat)
+		defer heartbeat.Stop()
+		var expired <-chan time.Time
+		if claims, ok := r.Context().Value("claims").(*models.Claims); ok && claims.ExpiresAt != 0 {
+			timer := time.NewTimer(time.Until(time.Unix(claims.ExpiresAt, 0)))
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-expired:
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+			case ev, ok := <-client.C:
+				if !ok {
+					// geride kaldı, istemci Last-Event-ID ile yeniden bağlanır
+					return
+				}
+				if ev.Seq <= sent {
+					continue
+				}
+				sent = ev.Seq
+				if !canSeeEvent(actor, ev) {
+					continue
+				}
+				if err := writeEvent(w, ev); err != nil {
+					log.Println("Error streaming event: ", err)
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	})
+}
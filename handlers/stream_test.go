@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"task-management-system/events"
+	"task-management-system/models"
+	"testing"
+	"time"
+)
+
+// stream opens the event stream with the Last-Event-ID header and the
+// last_event_id parameter, when not empty, and returns the messages written
+// before the stream waits for new events, as "<id> <event>" or "resync".
+func (a *testApp) stream(t *testing.T, token, lastEventID, param string) (int, []string) {
+	t.Helper()
+	path := "/events"
+	if param != "" {
+		path += "?last_event_id=" + param
+	}
+	// the stream ends right after the replay
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", path, nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+
+	var messages []string
+	for _, block := range strings.Split(strings.TrimSpace(w.Body.String()), "\n\n") {
+		fields := map[string]string{}
+		for _, line := range strings.Split(block, "\n") {
+			if name, value, ok := strings.Cut(line, ": "); ok {
+				fields[name] = value
+			}
+		}
+		switch {
+		case fields["event"] == "resync":
+			messages = append(messages, "resync")
+		case fields["id"] != "":
+			messages = append(messages, fields["id"]+" "+fields["event"])
+		}
+	}
+	return w.Code, messages
+}
+
+func TestStreamEventsReplay(t *testing.T) {
+	a := newTestApp(t)
+	a.StreamHeartbeat = time.Minute
+	a.Stream = events.NewHub(10)
+	a.Events.Bus.Subscribe(func(ev models.Event) error { return a.Stream.Publish(ev) })
+	_, aliceToken := a.signUp(t, "alice", "admin")
+	bob, bobToken := a.signUp(t, "bob", defaultRole)
+
+	// bob sees the events 1, 3 and 4 but not those of alice's own task
+	create := func(assignee int) {
+		w := a.do(t, aliceToken, "POST", "/tasks", models.Task{Title: "task", AssignedTo: assignee})
+		expectStatus(t, w, http.StatusCreated)
+		if err := a.Events.Dispatch(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create(bob.ID)
+	create(0)
+	create(bob.ID)
+	create(bob.ID)
+
+	tests := []struct {
+		name string
+		// hub replaces the hub of the app when set, e.g. one of a restarted
+		// server
+		hub                *events.Hub
+		lastEventID, param string
+		wantStatus         int
+		want               []string
+	}{
+		{"new client", nil, "", "", http.StatusOK, nil},
+		{"up to date", nil, "4", "", http.StatusOK, nil},
+		{"from the hub", nil, "1", "", http.StatusOK, []string{"3 task.created", "4 task.created"}},
+		{"parameter", nil, "", "2", http.StatusOK, []string{"3 task.created", "4 task.created"}},
+		{"header before parameter", nil, "3", "1", http.StatusOK, []string{"4 task.created"}},
+		{"after a restart", events.NewHub(10), "0", "", http.StatusOK, nil},
+		{"from the outbox after a restart", events.NewHub(10), "1", "", http.StatusOK, []string{"3 task.created", "4 task.created"}},
+		{"invalid", nil, "latest", "", http.StatusBadRequest, nil},
+		{"negative", nil, "", "-1", http.StatusBadRequest, nil},
+	}
+	hub := a.Stream
+	for _, tt := range tests {
+		a.Stream = hub
+		if tt.hub != nil {
+			a.Stream = tt.hub
+		}
+		status, got := a.stream(t, bobToken, tt.lastEventID, tt.param)
+		if status != tt.wantStatus || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %d %q, want %d %q", tt.name, status, got, tt.wantStatus, tt.want)
+		}
+	}
+
+	// the events up to 4 are purged, a client further behind has to resync
+	a.Stream = hub
+	if _, err := a.Outbox.PurgeEvents(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	create(bob.ID)
+	a.Stream = events.NewHub(10)
+	if _, got := a.stream(t, bobToken, "1", ""); !reflect.DeepEqual(got, []string{"resync", "5 task.created"}) {
+		t.Errorf("after the purge got %q, want a resync and event 5", got)
+	}
+	if _, got := a.stream(t, bobToken, "4", ""); !reflect.DeepEqual(got, []string{"5 task.created"}) {
+		t.Errorf("after the purge from event 4 got %q, want event 5", got)
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"task-management-system/authz"
+	"task-management-system/models"
+	"task-management-system/store"
+
+	"github.com/gorilla/mux"
+)
+
+// checkParent tells whether the task may become a subtask of parentID: the
+// parent must be a live task the user may update, and the task must not be
+// one of the ancestors of the parent.
+func (db *AppHandler) checkParent(r *http.Request, taskID, parentID int) (int, error) {
+	parent, err := db.Tasks.GetTask(parentID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && parent.DeletedAt != nil) {
+		return http.StatusNotFound, fmt.Errorf("parent task %d not found", parentID)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !authz.Can(authz.ActorFromContext(r.Context()), authz.ActionUpdate, parent) {
+		return http.StatusForbidden, fmt.Errorf("not allowed to add subtasks to task %d", parentID)
+	}
+
+	// üst görevden köke kadar yürü, görevin kendisine rastlarsak döngü olur
+	seen := map[int]bool{}
+	for ancestor := &parent; ; {
+		if ancestor.ID == taskID {
+			return http.StatusUnprocessableEntity, store.ErrSubtaskCycle
+		}
+		if seen[ancestor.ID] {
+			return http.StatusInternalServerError, fmt.Errorf("the parents of task %d form a cycle", parentID)
+		}
+		seen[ancestor.ID] = true
+		if ancestor.ParentID == nil {
+			return 0, nil
+		}
+		next, err := db.Tasks.GetTask(*ancestor.ParentID)
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, nil
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		ancestor = &next
+	}
+}
+
+// taskProgress rolls the completion of the subtasks up to the task. It
+// returns nil for tasks without subtasks.
+func (db *AppHandler) taskProgress(taskID int) (*models.TaskProgress, error) {
+	return db.subtreeProgress(taskID, map[int]bool{})
+}
+
+// subtreeProgress is taskProgress for a task below the tasks in seen, which
+// it refuses to visit twice.
+func (db *AppHandler) subtreeProgress(taskID int, seen map[int]bool) (*models.TaskProgress, error) {
+	if seen[taskID] {
+		return nil, fmt.Errorf("task %d is its own subtask", taskID)
+	}
+	seen[taskID] = true
+
+	subtasks, err := db.Tasks.ListSubtasks(taskID)
+	if err != nil || len(subtasks) == 0 {
+		return nil, err
+	}
+
+	progress := &models.TaskProgress{Subtasks: len(subtasks)}
+	total := 0
+	for _, subtask := range subtasks {
+		if db.Workflow.IsDone(subtask.Status) {
+			progress.Completed++
+			total += 100
+			continue
+		}
+		child, err := db.subtreeProgress(subtask.ID, seen)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			total += child.Percent
+		}
+	}
+	progress.Percent = total / len(subtasks)
+	return progress, nil
+}
+
+// GetSubtasks godoc
+// @Summary List the subtasks of a task
+// @Description Get the direct subtasks of a task that the user can see
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} models.Task
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/subtasks [get]
+func (db *AppHandler) GetSubtasks() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		subtasks, err := db.Tasks.ListSubtasks(task.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		actor := authz.ActorFromContext(r.Context())
+		visible := []models.Task{}
+		for _, subtask := range subtasks {
+			if authz.Can(actor, authz.ActionView, subtask) {
+				visible = append(visible, subtask)
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(visible)
+	})
+}
+
+// AttachSubtask godoc
+// @Summary Attach a subtask
+// @Description Make an existing task a subtask of this task. A task that already has a parent is moved. Cycles are rejected with 422.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Parent task ID"
+// @Param subtask body models.SubtaskRequest true "Subtask"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 422 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/subtasks [post]
+func (db *AppHandler) AttachSubtask() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent := r.Context().Value("task").(models.Task)
+
+		var req models.SubtaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		subtask, err := db.Tasks.GetTask(req.TaskID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && subtask.DeletedAt != nil) {
+			http.Error(w, "Subtask not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !authz.Can(authz.ActorFromContext(r.Context()), authz.ActionUpdate, subtask) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if code, err := db.checkParent(r, subtask.ID, parent.ID); err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		before := subtask
+		subtask.ParentID = &parent.ID
+		event := taskEvent(r.Context().Value("userID").(int), &before, &subtask)
+		err = db.Tasks.SetTaskParent(subtask.ID, subtask.ParentID, event)
+		if errors.Is(err, store.ErrSubtaskCycle) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(subtask)
+	})
+}
+
+// DetachSubtask godoc
+// @Summary Detach a subtask
+// @Description Turn a subtask of this task into a top level task
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Parent task ID"
+// @Param subtask_id path int true "Subtask ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/subtasks/{subtask_id} [delete]
+func (db *AppHandler) DetachSubtask() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent := r.Context().Value("task").(models.Task)
+
+		subtaskID, err := strconv.Atoi(mux.Vars(r)["subtask_id"])
+		if err != nil {
+			http.Error(w, "Invalid subtask ID", http.StatusBadRequest)
+			return
+		}
+
+		subtask, err := db.Tasks.GetTask(subtaskID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && (subtask.ParentID == nil || *subtask.ParentID != parent.ID)) {
+			http.Error(w, "Subtask not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		before := subtask
+		subtask.ParentID = nil
+		event := taskEvent(r.Context().Value("userID").(int), &before, &subtask)
+		if err := db.Tasks.SetTaskParent(subtask.ID, nil, event); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(subtask)
+	})
+}
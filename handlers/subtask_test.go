@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"task-management-system/models"
+	"task-management-system/store"
+	"testing"
+)
+
+// corruptParents reports the parents in the map instead of the stored ones,
+// like a database in which the parents already form a cycle.
+type corruptParents struct {
+	store.TaskStore
+	parents map[int]int
+}
+
+func (s corruptParents) GetTask(id int) (models.Task, error) {
+	task, err := s.TaskStore.GetTask(id)
+	if parent, ok := s.parents[id]; ok {
+		task.ParentID = &parent
+	}
+	return task, err
+}
+
+func (s corruptParents) ListSubtasks(parentID int) ([]models.Task, error) {
+	var subtasks []models.Task
+	for id, parent := range s.parents {
+		if parent == parentID {
+			task, err := s.GetTask(id)
+			if err != nil {
+				return nil, err
+			}
+			subtasks = append(subtasks, task)
+		}
+	}
+	return subtasks, nil
+}
+
+func (a *testApp) newTask(t *testing.T, title string, owner int) models.Task {
+	t.Helper()
+	task := models.Task{Title: title, Status: "pending", Priority: models.PriorityMedium, UserID: owner, AssignedTo: owner}
+	if err := a.Tasks.CreateTask(&task, nil); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestAttachSubtaskRejectsCycles(t *testing.T) {
+	a := newTestApp(t)
+	alice, token := a.signUp(t, "alice", "admin")
+	root := a.newTask(t, "root", alice.ID)
+	mid := a.newTask(t, "mid", alice.ID)
+	leaf := a.newTask(t, "leaf", alice.ID)
+	attach := func(parent, child models.Task) *http.Response {
+		w := a.do(t, token, "POST", "/tasks/"+strconv.Itoa(parent.ID)+"/subtasks", models.SubtaskRequest{TaskID: child.ID})
+		return w.Result()
+	}
+	if res := attach(root, mid); res.StatusCode != http.StatusOK {
+		t.Fatalf("attach mid: status %d", res.StatusCode)
+	}
+	if res := attach(mid, leaf); res.StatusCode != http.StatusOK {
+		t.Fatalf("attach leaf: status %d", res.StatusCode)
+	}
+
+	tests := []struct {
+		name          string
+		parent, child models.Task
+	}{
+		{"itself", root, root},
+		{"its child", mid, root},
+		{"its grandchild", leaf, root},
+		{"a child under its own child", leaf, mid},
+	}
+	for _, tt := range tests {
+		if res := attach(tt.parent, tt.child); res.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status = %d, want 422", tt.name, res.StatusCode)
+		}
+	}
+	if stored, _ := a.Tasks.GetTask(root.ID); stored.ParentID != nil {
+		t.Errorf("root got the parent %d", *stored.ParentID)
+	}
+
+	// moving a subtask elsewhere in its own tree is fine
+	if res := attach(root, leaf); res.StatusCode != http.StatusOK {
+		t.Errorf("moving leaf under root: status %d", res.StatusCode)
+	}
+}
+
+func TestSubtaskWalksStopAtCorruptCycles(t *testing.T) {
+	a := newTestApp(t)
+	alice, token := a.signUp(t, "alice", "admin")
+	task := a.newTask(t, "task", alice.ID)
+	one := a.newTask(t, "one", alice.ID)
+	two := a.newTask(t, "two", alice.ID)
+	a.Tasks = corruptParents{TaskStore: a.Tasks, parents: map[int]int{one.ID: two.ID, two.ID: one.ID}}
+
+	w := a.do(t, token, "POST", "/tasks/"+strconv.Itoa(one.ID)+"/subtasks", models.SubtaskRequest{TaskID: task.ID})
+	expectStatus(t, w, http.StatusInternalServerError)
+
+	if _, err := a.taskProgress(one.ID); err == nil {
+		t.Error("taskProgress of a task in a cycle returned no error")
+	}
+}
+
+func TestTaskProgress(t *testing.T) {
+	a := newTestApp(t)
+	root := a.newTask(t, "root", 1)
+	if progress, err := a.taskProgress(root.ID); err != nil || progress != nil {
+		t.Fatalf("progress of a task without subtasks = %+v, %v", progress, err)
+	}
+
+	// root has a completed subtask and one that is half done
+	done := a.newTask(t, "done", 1)
+	half := a.newTask(t, "half", 1)
+	for _, child := range []models.Task{done, half} {
+		if err := a.Tasks.SetTaskParent(child.ID, &root.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done.Status = "completed"
+	if err := a.Tasks.UpdateTask(done, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []string{"completed", "pending"} {
+		grandchild := a.newTask(t, "grandchild", 1)
+		grandchild.Status = status
+		if err := a.Tasks.UpdateTask(grandchild, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Tasks.SetTaskParent(grandchild.ID, &half.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	progress, err := a.taskProgress(root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (models.TaskProgress{Subtasks: 2, Completed: 1, Percent: 75}); *progress != want {
+		t.Errorf("progress = %+v, want %+v", *progress, want)
+	}
+}
@@ -2,17 +2,21 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"task-management-system/authz"
 	"task-management-system/models"
+	"task-management-system/store"
 	"time"
-
-	"github.com/gorilla/mux"
 )
 
 // CreateTask godoc
 // @Summary Create a new task
-// @Description Create a new task and assign it to a user
+// @Description Create a new task and assign it to a user. The priority defaults to medium. With parent_id the task is created as a subtask.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
@@ -20,6 +24,7 @@ import (
 // @Success 201 {object} models.Task
 // @Failure 400 {object} string
 // @Failure 401 {object} string
+// @Failure 403 {object} string
 // @Failure 500 {object} string
 // @Router /tasks [post]
 func (db *AppHandler) CreateTask() http.Handler {
@@ -34,10 +39,19 @@ func (db *AppHandler) CreateTask() http.Handler {
 		userID := r.Context().Value("userID").(int)
 		task.UserID = userID
 
-		//role'ü de contexten alalım
-		role := r.Context().Value("role").(string)
-		if role != "admin" {
-			http.Error(w, "Only admin can create tasks", http.StatusUnauthorized)
+		if task.Status == "" {
+			task.Status = db.Workflow.Initial
+		}
+		if !db.Workflow.IsStatus(task.Status) {
+			http.Error(w, fmt.Sprintf("unknown status %q, expected one of: %s", task.Status, strings.Join(db.Workflow.Statuses(), ", ")), http.StatusBadRequest)
+			return
+		}
+
+		if task.Priority == "" {
+			task.Priority = models.PriorityMedium
+		}
+		if err := validatePriority(task.Priority); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
@@ -55,21 +69,99 @@ func (db *AppHandler) CreateTask() http.Handler {
 			return
 		}
 
-		_, err = db.DB.Exec("INSERT INTO tasks (title, description, status, start_date, due_date, user_id, assigned_to) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			task.Title, task.Description, task.Status, task.StartDate, task.DueDate, task.UserID, task.AssignedTo)
-		if err != nil {
+		if task.ParentID != nil {
+			if code, err := db.checkParent(r, 0, *task.ParentID); err != nil {
+				http.Error(w, err.Error(), code)
+				return
+			}
+		}
+
+		if err := db.Tasks.CreateTask(&task, taskEvent(userID, nil, &task)); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		db.Events.Wake()
 
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(task)
 	})
 }
 
+// GetTask godoc
+// @Summary Get a task
+// @Description Get a single task with its creator, assignee and the completion of its subtasks. Returns 404 when the task does not exist and 403 when it exists but is not visible to the user.
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {object} models.TaskDetail
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id} [get]
+func (db *AppHandler) GetTask() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		detail, err := db.taskDetail(task, r.Context().Value("userID").(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(detail)
+	})
+}
+
+// taskDetail adds the related records of a task as seen by the viewer.
+func (db *AppHandler) taskDetail(task models.Task, viewerID int) (models.TaskDetail, error) {
+	detail := models.TaskDetail{Task: task}
+	var err error
+	if detail.Creator, err = db.userSummary(task.UserID); err != nil {
+		return detail, err
+	}
+	if detail.Assignee, err = db.userSummary(task.AssignedTo); err != nil {
+		return detail, err
+	}
+	if detail.Progress, err = db.taskProgress(task.ID); err != nil {
+		return detail, err
+	}
+	if detail.Labels, err = db.Labels.ListTaskLabels(task.ID, viewerID); err != nil {
+		return detail, err
+	}
+	if detail.Labels == nil {
+		detail.Labels = []models.Label{}
+	}
+	return detail, nil
+}
+
+func validatePriority(priority string) error {
+	if !models.IsPriority(priority) {
+		return fmt.Errorf("unknown priority %q, expected one of: %s", priority, strings.Join(models.Priorities, ", "))
+	}
+	return nil
+}
+
+// userSummary returns nil for unassigned (0) or deleted users.
+func (db *AppHandler) userSummary(userID int) (*models.UserSummary, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+	user, err := db.Users.GetUser(userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserSummary{ID: user.ID, Username: user.Username}, nil
+}
+
 // UpdateTask godoc
 // @Summary Update an existing task
-// @Description Update an existing task with new details
+// @Description Update an existing task with new details. Assignees may only change the status. Status changes must follow the workflow.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
@@ -78,29 +170,37 @@ func (db *AppHandler) CreateTask() http.Handler {
 // @Success 200 {object} models.Task
 // @Failure 400 {object} string
 // @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 422 {object} string
 // @Failure 500 {object} string
 // @Router /tasks/{task_id} [put]
 func (db *AppHandler) UpdateTask() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		taskID := vars["task_id"]
+		existingTask := r.Context().Value("task").(models.Task)
+		before := existingTask
 
 		var task models.Task
 		if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-
-		userRole := r.Context().Value("role").(string)
-		if userRole != "admin" {
-			http.Error(w, "Only admin can update tasks", http.StatusInternalServerError)
-			return
+		if task.Priority != "" {
+			if err := validatePriority(task.Priority); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 		}
 
-		var existingTask models.Task
-		row := db.DB.QueryRow("SELECT id, title, description, status, start_date, due_date, assigned_to FROM tasks WHERE id = ?", taskID)
-		if err := row.Scan(&existingTask.ID, &existingTask.Title, &existingTask.Description, &existingTask.Status, &existingTask.StartDate, &existingTask.DueDate, &existingTask.AssignedTo); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		// sadece durum değişikliği atanan kişiye de açık, diğer alanlar için düzenleme yetkisi gerekir
+		editsFields := (task.Title != "" && task.Title != existingTask.Title) ||
+			(task.Description != "" && task.Description != existingTask.Description) ||
+			(task.Priority != "" && task.Priority != existingTask.Priority) ||
+			(!task.StartDate.IsZero() && !task.StartDate.Equal(existingTask.StartDate)) ||
+			(!task.DueDate.IsZero() && !task.DueDate.Equal(existingTask.DueDate)) ||
+			(task.AssignedTo != 0 && task.AssignedTo != existingTask.AssignedTo)
+		if editsFields && !authz.Can(authz.ActorFromContext(r.Context()), authz.ActionUpdate, existingTask) {
+			http.Error(w, "Only the status of this task can be changed", http.StatusForbidden)
 			return
 		}
 
@@ -110,76 +210,142 @@ func (db *AppHandler) UpdateTask() http.Handler {
 		if task.Description != "" {
 			existingTask.Description = task.Description
 		}
-		if task.Status != "" {
+		if task.Priority != "" {
+			existingTask.Priority = task.Priority
+		}
+		if task.Status != "" && task.Status != existingTask.Status {
+			if err := db.checkTransition(existingTask, task.Status); err != nil {
+				writeTransitionError(w, err)
+				return
+			}
 			existingTask.Status = task.Status
 		}
 		if !task.StartDate.IsZero() {
 			existingTask.StartDate = task.StartDate
 		}
-		if !task.DueDate.IsZero() {
+		if !task.DueDate.IsZero() && !task.DueDate.Equal(existingTask.DueDate) {
 			existingTask.DueDate = task.DueDate
+			// yeni bitiş tarihi için hatırlatmalar baştan başlar
+			existingTask.OverdueAt = nil
 		}
 		if task.AssignedTo != 0 {
 			existingTask.AssignedTo = task.AssignedTo
 		}
 
-		_, err := db.DB.Exec("UPDATE tasks SET title = ?, description = ?, status = ?, start_date = ?, due_date = ?, assigned_to = ? WHERE id = ?",
-			existingTask.Title, existingTask.Description, existingTask.Status, existingTask.StartDate, existingTask.DueDate, existingTask.AssignedTo, taskID)
-		if err != nil {
+		event := taskEvent(r.Context().Value("userID").(int), &before, &existingTask)
+		if err := db.Tasks.UpdateTask(existingTask, event); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		db.Events.Wake()
 
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(task)
+		json.NewEncoder(w).Encode(existingTask)
 
 	})
 }
 
 // DeleteTask godoc
 // @Summary Delete a task
-// @Description Delete a task by task ID
+// @Description Move a task to the trash. It can be restored until it is purged after the retention period.
 // @Tags tasks
 // @Param task_id path int true "Task ID"
 // @Success 200 {object} string
 // @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
 // @Failure 500 {object} string
 // @Router /tasks/{task_id} [delete]
 func (db *AppHandler) DeleteTask() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		taskID := vars["task_id"]
+		task := r.Context().Value("task").(models.Task)
+		userID := r.Context().Value("userID").(int)
+
+		err := db.Tasks.TrashTask(task.ID, userID, time.Now(), taskEvent(userID, &task, nil))
+		if errors.Is(err, store.ErrNotFound) {
+			// aynı anda başka bir istekle silinmiş
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
 
-		userRole := r.Context().Value("role").(string)
-		if userRole != "admin" {
-			http.Error(w, "Only admin can delete tasks", http.StatusInternalServerError)
+// RestoreTask godoc
+// @Summary Restore a deleted task
+// @Description Take a task out of the trash
+// @Tags tasks
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/restore [post]
+func (db *AppHandler) RestoreTask() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+		if task.DeletedAt == nil {
+			http.Error(w, "Task is not in the trash", http.StatusConflict)
 			return
 		}
 
-		_, err := db.DB.Exec("DELETE FROM tasks WHERE id = ?", taskID)
+		restored := task
+		restored.DeletedAt, restored.DeletedBy = nil, nil
+		err := db.Tasks.RestoreTask(task.ID, taskEvent(r.Context().Value("userID").(int), &task, &restored))
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Task is not in the trash", http.StatusConflict)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		db.Events.Wake()
 
 		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(restored)
 	})
 }
 
 // GetTasks godoc
 // @Summary Get tasks for the user
-// @Description Get all tasks assigned to the user or created by the admin
+// @Description Get one page of the tasks the user can view: with task:read:any all tasks, with task:read the tasks the user created or is assigned to. Tasks in the trash are only listed with trashed=true. The total number of matching tasks is returned in X-Total-Count, the cursor of the next page in X-Next-Cursor.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
+// @Param status query string false "Comma separated statuses"
+// @Param priority query string false "Comma separated priorities"
+// @Param assigned_to query int false "Assignee user ID"
+// @Param created_by query int false "Creator user ID"
+// @Param due_from query string false "Due date lower bound (RFC3339)"
+// @Param due_to query string false "Due date upper bound (RFC3339)"
+// @Param start_from query string false "Start date lower bound (RFC3339)"
+// @Param start_to query string false "Start date upper bound (RFC3339)"
+// @Param label query string false "Comma separated names of the user's labels, matches tasks with any of them"
+// @Param trashed query bool false "List the tasks in the trash instead"
+// @Param overdue query bool false "Only tasks marked overdue"
+// @Param sort query string false "id, title, status, priority, start_date or due_date, prefix with - for descending"
+// @Param limit query int false "Page size, default 50, max 200"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
 // @Success 200 {array} models.Task
+// @Header 200 {integer} X-Total-Count "Number of matching tasks"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, empty on the last page"
+// @Failure 400 {object} string
 // @Failure 401 {object} string
 // @Failure 500 {object} string
 // @Router /tasks [get]
 func (db *AppHandler) GetTasks() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var tasks []models.Task
-
 		userIDValue := r.Context().Value("userID")
 		userRoleValue := r.Context().Value("role")
 		// userID veya role nil mi kontrol et
@@ -205,42 +371,90 @@ func (db *AppHandler) GetTasks() http.Handler {
 
 		log.Printf("User ID: %d, Role: %s", userID, userRole)
 
-		if userRole == "admin" {
-			rows, err := db.DB.Query("SELECT * FROM tasks WHERE user_id = ?", userID)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			defer rows.Close()
-
-			for rows.Next() {
-				var task models.Task
-				if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.StartDate, &task.DueDate, &task.UserID, &task.AssignedTo); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
-					return
-				}
-				tasks = append(tasks, task)
-			}
+		q, err := parseTaskQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		} else if userRole == "user" {
-			rows, err := db.DB.Query("SELECT * FROM tasks WHERE assigned_to = ?", userID)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			defer rows.Close()
-
-			for rows.Next() {
-				var task models.Task
-				if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.StartDate, &task.DueDate, &task.UserID, &task.AssignedTo); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
-					return
-				}
-				tasks = append(tasks, task)
-			}
+		q.LabelIDs, err = db.labelIDs(userID, r.URL.Query().Get("label"))
+		var unknownLabel *unknownLabelError
+		if errors.As(err, &unknownLabel) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// task:read:any yetkisi yoksa sadece oluşturduğu veya atandığı görevler
+		q.VisibleTo = authz.ViewScope(authz.ActorFromContext(r.Context()))
+
+		page, err := db.Tasks.ListTasks(q)
+		if errors.Is(err, store.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
+		tasks := page.Tasks
+		if tasks == nil {
+			tasks = []models.Task{}
+		}
+
+		w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+		if page.NextCursor != "" {
+			w.Header().Set("X-Next-Cursor", page.NextCursor)
+		}
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(tasks)
 	})
 }
+
+func parseTaskQuery(r *http.Request) (store.TaskQuery, error) {
+	values := r.URL.Query()
+	var q store.TaskQuery
+	var err error
+
+	if status := values.Get("status"); status != "" {
+		q.Statuses = strings.Split(status, ",")
+	}
+	if priority := values.Get("priority"); priority != "" {
+		q.Priorities = strings.Split(priority, ",")
+		for _, p := range q.Priorities {
+			if err := validatePriority(p); err != nil {
+				return q, err
+			}
+		}
+	}
+	for name, dst := range map[string]*int{"assigned_to": &q.AssignedTo, "created_by": &q.CreatedBy, "limit": &q.Limit} {
+		if v := values.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil || *dst < 0 {
+				return q, fmt.Errorf("invalid %s", name)
+			}
+		}
+	}
+	for name, dst := range map[string]*time.Time{"due_from": &q.DueFrom, "due_to": &q.DueTo, "start_from": &q.StartFrom, "start_to": &q.StartTo} {
+		if v := values.Get(name); v != "" {
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				return q, fmt.Errorf("invalid %s, expected RFC3339", name)
+			}
+		}
+	}
+	for name, dst := range map[string]*bool{"trashed": &q.Trashed, "overdue": &q.Overdue} {
+		if v := values.Get(name); v != "" {
+			if *dst, err = strconv.ParseBool(v); err != nil {
+				return q, fmt.Errorf("invalid %s", name)
+			}
+		}
+	}
+	if q.Sort, err = store.ParseTaskSort(values.Get("sort")); err != nil {
+		return q, err
+	}
+	q.Cursor = values.Get("cursor")
+	return q, nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"task-management-system/models"
+	"task-management-system/store"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens signs a new access token for the user and stores a new refresh
+// token in the given family. An empty familyID starts a new family (login).
+func (db *AppHandler) issueTokens(user models.User, familyID string) (models.TokenResponse, time.Time, error) {
+	now := time.Now()
+	expirationTime := now.Add(db.AccessTokenTTL)
+	jti, err := randomToken()
+	if err != nil {
+		return models.TokenResponse{}, expirationTime, err
+	}
+	claims := &models.Claims{
+		Username: user.Username,
+		UserID:   user.ID,
+		Role:     user.Role,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			ExpiresAt: expirationTime.Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(db.JWTKey)
+	if err != nil {
+		return models.TokenResponse{}, expirationTime, err
+	}
+
+	if familyID == "" {
+		if familyID, err = randomToken(); err != nil {
+			return models.TokenResponse{}, expirationTime, err
+		}
+	}
+	refreshToken, err := randomToken()
+	if err != nil {
+		return models.TokenResponse{}, expirationTime, err
+	}
+	err = db.RefreshTokens.CreateRefreshToken(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(db.RefreshTokenTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return models.TokenResponse{}, expirationTime, err
+	}
+
+	return models.TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(db.AccessTokenTTL.Seconds()),
+	}, expirationTime, nil
+}
+
+// RefreshToken godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once; presenting a used token revokes its whole family.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param refresh body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 500 {object} string
+// @Router /token/refresh [post]
+func (db *AppHandler) RefreshToken() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.RefreshToken == "" {
+			http.Error(w, "Missing refresh token", http.StatusBadRequest)
+			return
+		}
+
+		stored, err := db.RefreshTokens.GetRefreshTokenByHash(hashToken(req.RefreshToken))
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		if stored.RevokedAt != nil {
+			// Eski bir token tekrar kullanıldı: token çalınmış olabilir, tüm aileyi iptal et
+			if err := db.RefreshTokens.RevokeRefreshTokenFamily(stored.FamilyID, now); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			log.Printf("Refresh token reuse detected for user %d, family revoked", stored.UserID)
+			http.Error(w, "Refresh token reuse detected", http.StatusUnauthorized)
+			return
+		}
+		if now.After(stored.ExpiresAt) {
+			http.Error(w, "Refresh token expired", http.StatusUnauthorized)
+			return
+		}
+
+		rotated, err := db.RefreshTokens.RevokeRefreshToken(stored.ID, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !rotated {
+			// aynı token eşzamanlı olarak başka bir istekte kullanıldı
+			if err := db.RefreshTokens.RevokeRefreshTokenFamily(stored.FamilyID, now); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.Error(w, "Refresh token reuse detected", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := db.Users.GetUser(stored.UserID)
+		if err != nil {
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		resp, _, err := db.issueTokens(user, stored.FamilyID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
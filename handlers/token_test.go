@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"task-management-system/models"
+	"testing"
+	"time"
+)
+
+func (a *testApp) refresh(t *testing.T, refreshToken string) (*http.Response, models.TokenResponse) {
+	t.Helper()
+	w := a.do(t, "", "POST", "/token/refresh", models.RefreshRequest{RefreshToken: refreshToken})
+	var tokens models.TokenResponse
+	if w.Code == http.StatusOK {
+		decode(t, w, &tokens)
+	}
+	return w.Result(), tokens
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	a := newTestApp(t)
+	_, login := a.signUpTokens(t, "alice", defaultRole)
+
+	res, first := a.refresh(t, login.RefreshToken)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("refresh status = %d", res.StatusCode)
+	}
+	if first.Token == "" || first.RefreshToken == "" || first.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh returned %+v, want a new access and refresh token", first)
+	}
+	expectStatus(t, a.do(t, first.Token, "GET", "/tasks", nil), http.StatusOK)
+
+	// the rotated token stays in the family of the login
+	old, _ := a.RefreshTokens.GetRefreshTokenByHash(hashToken(login.RefreshToken))
+	rotated, _ := a.RefreshTokens.GetRefreshTokenByHash(hashToken(first.RefreshToken))
+	if old.RevokedAt == nil || rotated.RevokedAt != nil || rotated.FamilyID != old.FamilyID {
+		t.Errorf("old = %+v, rotated = %+v", old, rotated)
+	}
+
+	res, second := a.refresh(t, first.RefreshToken)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("second refresh status = %d", res.StatusCode)
+	}
+	if _, err := a.RefreshTokens.GetRefreshTokenByHash(hashToken(second.RefreshToken)); err != nil {
+		t.Errorf("the second rotation was not stored: %v", err)
+	}
+}
+
+func TestRefreshTokenRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		// tokens returns the refresh token to send and the latest token the
+		// client of the login holds.
+		tokens func(t *testing.T, a *testApp, user models.User, login models.TokenResponse) (send, latest string)
+		want   int
+		// familyRevoked is set when the request must end the session of the
+		// login, so its latest token no longer refreshes.
+		familyRevoked bool
+	}{
+		{
+			name: "missing",
+			tokens: func(_ *testing.T, _ *testApp, _ models.User, login models.TokenResponse) (string, string) {
+				return "", login.RefreshToken
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "unknown",
+			tokens: func(_ *testing.T, _ *testApp, _ models.User, login models.TokenResponse) (string, string) {
+				return "not-a-token", login.RefreshToken
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "access token instead of the refresh token",
+			tokens: func(_ *testing.T, _ *testApp, _ models.User, login models.TokenResponse) (string, string) {
+				return login.Token, login.RefreshToken
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			tokens: func(t *testing.T, a *testApp, user models.User, login models.TokenResponse) (string, string) {
+				err := a.RefreshTokens.CreateRefreshToken(&models.RefreshToken{
+					UserID: user.ID, FamilyID: "expired", TokenHash: hashToken("expired-token"),
+					ExpiresAt: time.Now().Add(-time.Minute), CreatedAt: time.Now().Add(-time.Hour),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return "expired-token", login.RefreshToken
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			// an attacker replays a token the client already rotated
+			name: "reused after rotation",
+			tokens: func(t *testing.T, a *testApp, _ models.User, login models.TokenResponse) (string, string) {
+				res, rotated := a.refresh(t, login.RefreshToken)
+				if res.StatusCode != http.StatusOK {
+					t.Fatalf("rotation status = %d", res.StatusCode)
+				}
+				return login.RefreshToken, rotated.RefreshToken
+			},
+			want:          http.StatusUnauthorized,
+			familyRevoked: true,
+		},
+		{
+			name: "revoked family",
+			tokens: func(t *testing.T, a *testApp, _ models.User, login models.TokenResponse) (string, string) {
+				stored, err := a.RefreshTokens.GetRefreshTokenByHash(hashToken(login.RefreshToken))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := a.RefreshTokens.RevokeRefreshTokenFamily(stored.FamilyID, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+				return login.RefreshToken, login.RefreshToken
+			},
+			want:          http.StatusUnauthorized,
+			familyRevoked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t)
+			user, login := a.signUpTokens(t, "alice", defaultRole)
+			send, latest := tt.tokens(t, a, user, login)
+
+			if res, _ := a.refresh(t, send); res.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+
+			res, _ := a.refresh(t, latest)
+			if tt.familyRevoked && res.StatusCode != http.StatusUnauthorized {
+				t.Errorf("the latest token of the session still refreshes (status %d)", res.StatusCode)
+			}
+			if !tt.familyRevoked && res.StatusCode != http.StatusOK {
+				t.Errorf("the session of the login was ended (status %d)", res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenOtherSessionsSurviveReuse(t *testing.T) {
+	a := newTestApp(t)
+	_, phone := a.signUpTokens(t, "alice", defaultRole)
+	w := a.do(t, "", "POST", "/login", models.User{Username: "alice", Password: "secret"})
+	expectStatus(t, w, http.StatusOK)
+	var laptop models.TokenResponse
+	decode(t, w, &laptop)
+
+	if res, _ := a.refresh(t, phone.RefreshToken); res.StatusCode != http.StatusOK {
+		t.Fatal("rotation failed")
+	}
+	if res, _ := a.refresh(t, phone.RefreshToken); res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("reuse was not detected")
+	}
+	// only the family of the reused token is revoked
+	if res, _ := a.refresh(t, laptop.RefreshToken); res.StatusCode != http.StatusOK {
+		t.Errorf("the other login was revoked too (status %d)", res.StatusCode)
+	}
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"task-management-system/events"
+	"task-management-system/models"
+	"task-management-system/store"
+	"task-management-system/webhook"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxDeliveryBatch is the number of due deliveries attempted per run.
+	maxDeliveryBatch = 100
+	maxDeliveryList  = 100
+	minSecretLength  = 16
+	maxErrorLength   = 1000
+)
+
+// EnqueueWebhooks creates a pending delivery of the event for every active
+// webhook subscribed to its type whose owner can see the event. It is
+// subscribed to the event bus, the deliveries are sent by DeliverWebhooks.
+// A webhook gets one delivery per event, also when the event is published
+// again.
+func (db *AppHandler) EnqueueWebhooks(ev models.Event) error {
+	webhooks, err := db.Webhooks.ListWebhooksForEvent(ev.Type)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	for _, w := range webhooks {
+		owner, err := db.Users.GetUser(w.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", w.ID, err))
+			continue
+		}
+		actor, err := db.actorFor(owner)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", w.ID, err))
+			continue
+		}
+		if !canSeeEvent(actor, ev) {
+			continue
+		}
+
+		delivery := models.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       ev.ID,
+			EventType:     ev.Type,
+			Payload:       payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+		if err := db.Webhooks.CreateDelivery(&delivery); err != nil && !errors.Is(err, store.ErrConflict) {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", w.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeliverWebhooks attempts the deliveries that are due. It returns the number
+// of attempts made.
+func (db *AppHandler) DeliverWebhooks(ctx context.Context, now time.Time) (int, error) {
+	due, err := db.Webhooks.ListDueDeliveries(now, maxDeliveryBatch)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for i, d := range due {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := db.attemptDelivery(ctx, d, now); err != nil {
+			errs = append(errs, fmt.Errorf("delivery %d: %w", d.ID, err))
+		}
+	}
+	return len(due), errors.Join(errs...)
+}
+
+// attemptDelivery sends the delivery once and records the outcome. Failures
+// of the receiver are recorded on the delivery, only store errors are
+// returned.
+func (db *AppHandler) attemptDelivery(ctx context.Context, d models.WebhookDelivery, now time.Time) error {
+	w, err := db.Webhooks.GetWebhook(d.WebhookID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.NextAttemptAt = nil
+	if !w.Active {
+		d.Status, d.Error = models.DeliveryFailed, "webhook is inactive"
+		return db.Webhooks.UpdateDelivery(d)
+	}
+
+	d.ResponseStatus, err = webhook.Send(ctx, db.WebhookClient, webhook.Request{
+		URL:        w.URL,
+		Secret:     w.Secret,
+		Event:      d.EventType,
+		DeliveryID: d.ID,
+		Body:       d.Payload,
+	}, now)
+	switch {
+	case err == nil:
+		d.Status, d.Error = models.DeliverySucceeded, ""
+	case d.Attempts >= db.WebhookMaxAttempts:
+		d.Status, d.Error = models.DeliveryFailed, truncate(err.Error(), maxErrorLength)
+	default:
+		next := now.Add(events.Backoff(d.Attempts, db.WebhookBackoff, db.WebhookMaxBackoff))
+		d.NextAttemptAt, d.Error = &next, truncate(err.Error(), maxErrorLength)
+	}
+	return db.Webhooks.UpdateDelivery(d)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func validateWebhook(w models.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	// adlar teslimde denetlenir, IP adresleri burada da
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !webhook.IsPublic(ip) {
+		return errors.New("url must not point to a private address")
+	}
+	if len(w.Secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+	if len(w.Events) == 0 {
+		return errors.New("events must not be empty")
+	}
+	for _, e := range w.Events {
+		if !models.IsEventType(e) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	return nil
+}
+
+// uniqueEvents removes duplicate event types, keeping the first of each.
+func uniqueEvents(list []string) []string {
+	seen := map[string]bool{}
+	events := []string{}
+	for _, e := range list {
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// userWebhook loads the webhook named by the {webhook_id} route variable.
+// Webhooks of other users are reported as not found.
+func (db *AppHandler) userWebhook(r *http.Request) (models.Webhook, int, error) {
+	webhookID, err := strconv.Atoi(mux.Vars(r)["webhook_id"])
+	if err != nil {
+		return models.Webhook{}, http.StatusBadRequest, errors.New("Invalid webhook ID")
+	}
+	w, err := db.Webhooks.GetWebhook(webhookID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && w.UserID != r.Context().Value("userID").(int)) {
+		return w, http.StatusNotFound, errors.New("Webhook not found")
+	}
+	if err != nil {
+		return w, http.StatusInternalServerError, err
+	}
+	return w, 0, nil
+}
+
+// GetWebhooks godoc
+// @Summary List webhooks
+// @Description Get the webhooks of the user, oldest first. Secrets are not included.
+// @Tags webhooks
+// @Produce  json
+// @Success 200 {array} models.Webhook
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks [get]
+func (db *AppHandler) GetWebhooks() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webhooks, err := db.Webhooks.ListWebhooks(r.Context().Value("userID").(int))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if webhooks == nil {
+			webhooks = []models.Webhook{}
+		}
+		for i := range webhooks {
+			webhooks[i].Secret = ""
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(webhooks)
+	})
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribe a URL to events: task.created, task.updated, task.deleted, task.restored, comment.created, comment.updated, comment.deleted, friendship.requested, friendship.accepted. Only events the user can see are sent. Every event is POSTed as JSON with the headers X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature, which is "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret. A secret is generated when none is given and is only returned in this response. The URL must resolve to a public address, this is checked again on every delivery. Responses other than 2xx, redirects included, are retried with exponential backoff.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param webhook body models.WebhookRequest true "Webhook"
+// @Success 201 {object} models.Webhook
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks [post]
+func (db *AppHandler) CreateWebhook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.WebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		hook := models.Webhook{
+			UserID:    r.Context().Value("userID").(int),
+			URL:       req.URL,
+			Secret:    req.Secret,
+			Events:    uniqueEvents(req.Events),
+			Active:    req.Active == nil || *req.Active,
+			CreatedAt: time.Now(),
+		}
+		if hook.Secret == "" {
+			secret, err := randomToken()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			hook.Secret = secret
+		}
+		if err := validateWebhook(hook); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := db.Webhooks.CreateWebhook(&hook); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(hook)
+	})
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce  json
+// @Param webhook_id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id} [get]
+func (db *AppHandler) GetWebhook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook, code, err := db.userWebhook(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		hook.Secret = ""
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(hook)
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Change the URL, secret, events or active flag of a webhook. Fields left out are kept. Pending deliveries are sent with the new settings.
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param webhook_id path int true "Webhook ID"
+// @Param webhook body models.WebhookRequest true "Webhook"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id} [put]
+func (db *AppHandler) UpdateWebhook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook, code, err := db.userWebhook(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		var req models.WebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.URL != "" {
+			hook.URL = req.URL
+		}
+		if req.Secret != "" {
+			hook.Secret = req.Secret
+		}
+		if req.Events != nil {
+			hook.Events = uniqueEvents(req.Events)
+		}
+		if req.Active != nil {
+			hook.Active = *req.Active
+		}
+		if err := validateWebhook(hook); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = db.Webhooks.UpdateWebhook(hook)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if req.Secret == "" {
+			hook.Secret = ""
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(hook)
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook together with its delivery log
+// @Tags webhooks
+// @Param webhook_id path int true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id} [delete]
+func (db *AppHandler) DeleteWebhook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook, code, err := db.userWebhook(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		err = db.Webhooks.DeleteWebhook(hook.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary List the deliveries of a webhook
+// @Description Get the latest deliveries of a webhook with their status, attempts and the last response, newest first
+// @Tags webhooks
+// @Produce  json
+// @Param webhook_id path int true "Webhook ID"
+// @Param limit query int false "Maximum number of deliveries (default and at most 100)"
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id}/deliveries [get]
+func (db *AppHandler) GetWebhookDeliveries() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook, code, err := db.userWebhook(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		limit := maxDeliveryList
+		if v := r.URL.Query().Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 || limit > maxDeliveryList {
+				http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxDeliveryList), http.StatusBadRequest)
+				return
+			}
+		}
+
+		deliveries, err := db.Webhooks.ListDeliveries(hook.ID, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deliveries == nil {
+			deliveries = []models.WebhookDelivery{}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(deliveries)
+	})
+}
+
+// RedeliverWebhook godoc
+// @Summary Redeliver an event
+// @Description Queue the event of an earlier delivery again as a new delivery with fresh attempts. It is sent with the current URL and secret of the webhook.
+// @Tags webhooks
+// @Produce  json
+// @Param webhook_id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Router /webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [post]
+func (db *AppHandler) RedeliverWebhook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook, code, err := db.userWebhook(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		deliveryID, err := strconv.Atoi(mux.Vars(r)["delivery_id"])
+		if err != nil {
+			http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+			return
+		}
+		previous, err := db.Webhooks.GetDelivery(deliveryID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && previous.WebhookID != hook.ID) {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !hook.Active {
+			http.Error(w, "Webhook is inactive", http.StatusConflict)
+			return
+		}
+
+		now := time.Now()
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       previous.EventID,
+			EventType:     previous.EventType,
+			Payload:       previous.Payload,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+		if err := db.Webhooks.CreateDelivery(&delivery); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(delivery)
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task-management-system/models"
+	"task-management-system/workflow"
+)
+
+// checkTransition tells whether the task may move to the given status: the
+// workflow must allow it and, for the statuses that require it, no blocker may be open.
+func (db *AppHandler) checkTransition(task models.Task, to string) error {
+	if err := db.Workflow.CheckTransition(task.Status, to); err != nil {
+		return err
+	}
+	if !db.Workflow.NeedsUnblocked(to) {
+		return nil
+	}
+	open, err := db.openBlockers(task.ID)
+	if err != nil {
+		return &lookupError{err}
+	}
+	if len(open) > 0 {
+		return &blockedError{To: to, Blockers: open}
+	}
+	return nil
+}
+
+// lookupError wraps failures to load what a check needs.
+type lookupError struct{ err error }
+
+func (e *lookupError) Error() string { return e.err.Error() }
+
+func writeTransitionError(w http.ResponseWriter, err error) {
+	var transitionErr *workflow.TransitionError
+	var blockedErr *blockedError
+	var lookupErr *lookupError
+	switch {
+	case errors.As(err, &transitionErr), errors.As(err, &blockedErr):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.As(err, &lookupErr):
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// GetWorkflow godoc
+// @Summary Get the task workflow
+// @Description Get the task statuses, the transitions allowed between them, the terminal and done statuses and the statuses that require a task to be unblocked
+// @Tags tasks
+// @Produce  json
+// @Success 200 {object} workflow.Workflow
+// @Failure 401 {object} string
+// @Router /workflow [get]
+func (db *AppHandler) GetWorkflow() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(db.Workflow)
+	})
+}
+
+// TransitionTask godoc
+// @Summary Change the status of a task
+// @Description Move a task to another status. Illegal transitions and moves to the statuses in workflow.requires_unblocked while blocking tasks are open are rejected with 422.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param transition body models.TransitionRequest true "Target status"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 422 {object} string
+// @Failure 500 {object} string
+// @Router /tasks/{task_id}/transitions [post]
+func (db *AppHandler) TransitionTask() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value("task").(models.Task)
+
+		var req models.TransitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := db.checkTransition(task, req.To); err != nil {
+			writeTransitionError(w, err)
+			return
+		}
+
+		before := task
+		task.Status = req.To
+		if err := db.Tasks.UpdateTask(task, taskEvent(r.Context().Value("userID").(int), &before, &task)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		db.Events.Wake()
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(task)
+	})
+}
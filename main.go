@@ -1,20 +1,35 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"task-management-system/authz"
+	"task-management-system/blob"
+	"task-management-system/config"
 	"task-management-system/db"
+	"task-management-system/events"
 	"task-management-system/handlers"
+	"task-management-system/jobs"
 	"task-management-system/middleware"
+	"task-management-system/migrations"
+	"task-management-system/models"
+	"task-management-system/notify"
+	"task-management-system/store"
+	"task-management-system/webhook"
 
 	_ "task-management-system/docs"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
+	_ "modernc.org/sqlite"
 )
 
 // @title Task Management API
@@ -29,32 +44,334 @@ import (
 // @host localhost:8080
 // @BasePath /
 func main() {
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	if secretKey == "" {
-		log.Fatal("JWT_SECRET_KEY env is not set")
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	db := db.InitDB("root:Eses147852@tcp(127.0.0.1:3306)/task_management?parseTime=true")
+	// ./task-management-system [flags] migrate up|down [n]|status
+	// ./task-management-system [flags] set-role <username> <role>
+	isMigrate := len(args) > 0 && args[0] == "migrate"
+	isSetRole := len(args) > 0 && args[0] == "set-role"
+	if isMigrate || isSetRole {
+		err = cfg.Database.Validate()
+	} else {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db := db.InitDB(cfg.Database.Driver, cfg.Database.DSN)
 	defer db.Close()
 	fmt.Println("Veritabanına bağlanıldı.")
 
+	migrator, err := migrations.New(db, cfg.Database.Driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if isMigrate {
+		if err := runMigrate(migrator, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := migrator.Up(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if isSetRole {
+		if err := runSetRole(&store.SQLUserStore{DB: db}, &store.SQLRoleStore{DB: db}, &store.SQLRevocationStore{DB: db}, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	blobs, err := newBlobStore(cfg.Attachments)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	notifier, err := newNotifier(cfg.Notifications, &store.SQLNotificationStore{DB: db}, &store.SQLUserStore{DB: db})
+	if err != nil {
+		log.Fatal(err)
+	}
+	go notifier.Run(context.Background())
+
+	outbox := &store.SQLOutboxStore{DB: db}
+	bus := events.NewBus()
+	dispatcher := events.NewDispatcher(outbox, bus, cfg.Events.DispatchInterval)
+	dispatcher.MaxAttempts = cfg.Events.MaxAttempts
+	dispatcher.Backoff, dispatcher.MaxBackoff = cfg.Events.Backoff, cfg.Events.MaxBackoff
+	hub := events.NewHub(cfg.Stream.ReplayBuffer)
+
 	r := mux.NewRouter()
 
-	appHandler := &handlers.AppHandler{DB: db}
+	revocations := &store.SQLRevocationStore{DB: db}
+	roles := &store.SQLRoleStore{DB: db}
+	appHandler := &handlers.AppHandler{
+		Tasks:           &store.SQLTaskStore{DB: db},
+		History:         &store.SQLTaskHistoryStore{DB: db},
+		Dependencies:    &store.SQLTaskDependencyStore{DB: db},
+		Labels:          &store.SQLLabelStore{DB: db},
+		Comments:        &store.SQLCommentStore{DB: db},
+		Notifications:   notifier.Store,
+		Attachments:     &store.SQLAttachmentStore{DB: db},
+		Recurrences:     &store.SQLRecurrenceStore{DB: db},
+		Reminders:       &store.SQLReminderStore{DB: db},
+		Users:           notifier.Users,
+		Friendships:     &store.SQLFriendshipStore{DB: db},
+		RefreshTokens:   &store.SQLRefreshTokenStore{DB: db},
+		Revocations:     revocations,
+		Roles:           roles,
+		Webhooks:        &store.SQLWebhookStore{DB: db},
+		Outbox:          outbox,
+		Notifier:        notifier,
+		Events:          dispatcher,
+		Stream:          hub,
+		JWTKey:          []byte(cfg.JWT.Secret),
+		AccessTokenTTL:  cfg.JWT.AccessTTL,
+		RefreshTokenTTL: cfg.JWT.RefreshTTL,
+		Workflow:        cfg.Workflow,
+
+		ReminderLeadHours: cfg.Reminders.LeadHours,
+		EscalationGrace:   cfg.Reminders.GracePeriod,
+
+		Blobs:                  blobs,
+		MaxAttachmentSize:      cfg.Attachments.MaxSize,
+		AllowedAttachmentTypes: cfg.Attachments.AllowedTypes,
+
+		WebhookClient:      webhook.NewClient(cfg.Webhooks.Timeout),
+		WebhookMaxAttempts: cfg.Webhooks.MaxAttempts,
+		WebhookBackoff:     cfg.Webhooks.Backoff,
+		WebhookMaxBackoff:  cfg.Webhooks.MaxBackoff,
+
+		StreamHeartbeat: cfg.Stream.Heartbeat,
+	}
+	bus.Subscribe(appHandler.EnqueueWebhooks)
+	bus.Subscribe(hub.Publish)
+	bus.Subscribe(appHandler.NotifyEvent)
+	go dispatcher.Run(context.Background())
+	auth := middleware.JWTMiddleware([]byte(cfg.JWT.Secret), revocations)
+
+	jobs.Every(context.Background(), "prune-revoked-tokens", cfg.JWT.PruneInterval, func(ctx context.Context) error {
+		_, err := revocations.PruneRevokedTokens(time.Now())
+		return err
+	})
+
+	jobs.Every(context.Background(), "purge-trashed-tasks", cfg.Tasks.PurgeInterval, func(ctx context.Context) error {
+		n, err := appHandler.Tasks.PurgeTasks(time.Now().Add(-cfg.Tasks.TrashRetention))
+		if n > 0 {
+			log.Printf("Purged %d tasks from the trash", n)
+		}
+		if err != nil {
+			return err
+		}
+		return purgeOrphanedAttachments(ctx, appHandler.Attachments, blobs)
+	})
+
+	jobs.Every(context.Background(), "generate-recurring-tasks", cfg.Tasks.RecurrenceInterval, func(ctx context.Context) error {
+		n, err := appHandler.GenerateRecurringTasks(time.Now())
+		if n > 0 {
+			log.Printf("Created %d recurring task occurrences", n)
+		}
+		return err
+	})
+
+	jobs.Every(context.Background(), "send-reminders", cfg.Reminders.Interval, func(ctx context.Context) error {
+		n, err := appHandler.SendReminders(time.Now())
+		if n > 0 {
+			log.Printf("Sent %d due date reminders", n)
+		}
+		return err
+	})
+
+	jobs.Every(context.Background(), "deliver-webhooks", cfg.Webhooks.Interval, func(ctx context.Context) error {
+		_, err := appHandler.DeliverWebhooks(ctx, time.Now())
+		return err
+	})
+
+	jobs.Every(context.Background(), "purge-webhook-deliveries", cfg.Tasks.PurgeInterval, func(ctx context.Context) error {
+		n, err := appHandler.Webhooks.PurgeDeliveries(time.Now().Add(-cfg.Webhooks.Retention))
+		if n > 0 {
+			log.Printf("Purged %d webhook deliveries", n)
+		}
+		return err
+	})
+
+	jobs.Every(context.Background(), "purge-events", cfg.Tasks.PurgeInterval, func(ctx context.Context) error {
+		n, err := outbox.PurgeEvents(time.Now().Add(-cfg.Events.Retention))
+		if n > 0 {
+			log.Printf("Purged %d events", n)
+		}
+		return err
+	})
+
+	can := func(permissions ...string) func(http.Handler) http.Handler {
+		return middleware.RequirePermission(roles, permissions...)
+	}
+	task := func(actions ...authz.Action) func(http.Handler) http.Handler {
+		return middleware.RequireTaskAccess(appHandler.Tasks, actions...)
+	}
+	trashedTask := func(actions ...authz.Action) func(http.Handler) http.Handler {
+		return middleware.RequireTrashedTaskAccess(appHandler.Tasks, actions...)
+	}
 
 	//routes
 	r.Handle("/register", appHandler.Register()).Methods("POST")
 	r.Handle("/login", appHandler.Login()).Methods("POST")
-	r.Handle("/tasks", middleware.JWTMiddleware(middleware.RoleMiddleware("admin")(appHandler.CreateTask()))).Methods("POST")
-	r.Handle("/tasks/{task_id}", middleware.JWTMiddleware(middleware.RoleMiddleware("admin")(appHandler.UpdateTask()))).Methods("PUT")
-	r.Handle("/tasks/{task_id}", middleware.JWTMiddleware(middleware.RoleMiddleware("admin")(appHandler.DeleteTask()))).Methods("DELETE")
-	r.Handle("/tasks", middleware.JWTMiddleware(middleware.RoleMiddleware("admin", "user")(appHandler.GetTasks()))).Methods("GET")
-	r.Handle("/user/stats", middleware.JWTMiddleware(appHandler.GetStats())).Methods("GET")
-	r.Handle("/friends", middleware.JWTMiddleware(appHandler.CreateFriendship())).Methods("POST")
-	r.Handle("/friends/accept", middleware.JWTMiddleware(appHandler.AcceptFriendRequest())).Methods("POST")
-	r.Handle("/friends/reject", middleware.JWTMiddleware(appHandler.RejectFriendRequest())).Methods("POST")
+	r.Handle("/token/refresh", appHandler.RefreshToken()).Methods("POST")
+	r.Handle("/logout", auth(appHandler.Logout())).Methods("POST")
+	r.Handle("/users/{user_id}/role", auth(can(models.PermUserAdmin)(appHandler.SetUserRole()))).Methods("PUT")
+	r.Handle("/users/{user_id}/sessions/revoke", auth(can(models.PermUserAdmin)(appHandler.RevokeUserSessions()))).Methods("POST")
+	r.Handle("/permissions", auth(can(models.PermUserAdmin)(appHandler.ListPermissions()))).Methods("GET")
+	r.Handle("/roles", auth(can(models.PermUserAdmin)(appHandler.ListRoles()))).Methods("GET")
+	r.Handle("/roles", auth(can(models.PermUserAdmin)(appHandler.CreateRole()))).Methods("POST")
+	r.Handle("/roles/{name}", auth(can(models.PermUserAdmin)(appHandler.UpdateRole()))).Methods("PUT")
+	r.Handle("/roles/{name}", auth(can(models.PermUserAdmin)(appHandler.DeleteRole()))).Methods("DELETE")
+	r.Handle("/tasks", auth(can(models.PermTaskCreate)(appHandler.CreateTask()))).Methods("POST")
+	r.Handle("/tasks/{task_id}", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetTask())))).Methods("GET")
+	r.Handle("/tasks/{task_id}", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn, models.PermTaskTransition)(
+		task(authz.ActionUpdate, authz.ActionTransition)(appHandler.UpdateTask())))).Methods("PUT")
+	r.Handle("/tasks/{task_id}", auth(can(models.PermTaskDeleteAny, models.PermTaskDeleteOwn)(
+		task(authz.ActionDelete)(appHandler.DeleteTask())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/restore", auth(can(models.PermTaskDeleteAny, models.PermTaskDeleteOwn)(
+		trashedTask(authz.ActionDelete)(appHandler.RestoreTask())))).Methods("POST")
+	r.Handle("/tasks", auth(can(models.PermTaskRead, models.PermTaskReadAny)(appHandler.GetTasks()))).Methods("GET")
+	r.Handle("/tasks/{task_id}/transitions", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn, models.PermTaskTransition)(
+		task(authz.ActionTransition)(appHandler.TransitionTask())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/subtasks", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetSubtasks())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/subtasks", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(appHandler.AttachSubtask())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/subtasks/{subtask_id}", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(appHandler.DetachSubtask())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/recurrence", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetRecurrence())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/recurrence", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(appHandler.SetRecurrence())))).Methods("PUT")
+	r.Handle("/tasks/{task_id}/recurrence", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(appHandler.DeleteRecurrence())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/dependencies", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetDependencies())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/dependencies", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(appHandler.AddDependency())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/dependencies/{blocker_id}", auth(can(models.PermTaskUpdateAny, models.PermTaskUpdateOwn)(
+		task(authz.ActionUpdate)(appHandler.RemoveDependency())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/labels/{label_id}", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.AddTaskLabel())))).Methods("PUT")
+	r.Handle("/tasks/{task_id}/labels/{label_id}", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.RemoveTaskLabel())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/comments", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetComments())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/comments", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(appHandler.CreateComment())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/comments/{comment_id}", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(appHandler.UpdateComment())))).Methods("PATCH")
+	r.Handle("/tasks/{task_id}/comments/{comment_id}", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(appHandler.DeleteComment())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/comments/{comment_id}/edits", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetCommentEdits())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/attachments", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.GetAttachments())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/attachments", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(appHandler.CreateAttachment())))).Methods("POST")
+	r.Handle("/tasks/{task_id}/attachments/{attachment_id}", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		task(authz.ActionView)(appHandler.DownloadAttachment())))).Methods("GET")
+	r.Handle("/tasks/{task_id}/attachments/{attachment_id}", auth(can(models.PermTaskComment)(
+		task(authz.ActionComment)(appHandler.DeleteAttachment())))).Methods("DELETE")
+	r.Handle("/tasks/{task_id}/history", auth(can(models.PermTaskRead, models.PermTaskReadAny)(
+		trashedTask(authz.ActionView)(appHandler.GetTaskHistory())))).Methods("GET")
+	r.Handle("/labels", auth(can(models.PermTaskRead)(appHandler.ListLabels()))).Methods("GET")
+	r.Handle("/labels", auth(can(models.PermTaskRead)(appHandler.CreateLabel()))).Methods("POST")
+	r.Handle("/labels/{label_id}", auth(can(models.PermTaskRead)(appHandler.UpdateLabel()))).Methods("PUT")
+	r.Handle("/labels/{label_id}", auth(can(models.PermTaskRead)(appHandler.DeleteLabel()))).Methods("DELETE")
+	r.Handle("/events", middleware.CookieToken("token")(auth(appHandler.StreamEvents()))).Methods("GET")
+	r.Handle("/notifications", auth(appHandler.GetNotifications())).Methods("GET")
+	r.Handle("/notifications/read", auth(appHandler.MarkAllNotificationsRead())).Methods("POST")
+	r.Handle("/notifications/{notification_id}/read", auth(appHandler.MarkNotificationRead())).Methods("PUT")
+	r.Handle("/notifications/{notification_id}/read", auth(appHandler.MarkNotificationUnread())).Methods("DELETE")
+	r.Handle("/workflow", auth(appHandler.GetWorkflow())).Methods("GET")
+	r.Handle("/user/stats", auth(appHandler.GetStats())).Methods("GET")
+	r.Handle("/user/reminder-preferences", auth(appHandler.GetReminderPreferences())).Methods("GET")
+	r.Handle("/user/reminder-preferences", auth(appHandler.UpdateReminderPreferences())).Methods("PUT")
+	r.Handle("/user/notification-preferences", auth(appHandler.GetNotificationPreferences())).Methods("GET")
+	r.Handle("/user/notification-preferences", auth(appHandler.UpdateNotificationPreferences())).Methods("PUT")
+	r.Handle("/webhooks", auth(can(models.PermWebhookManage)(appHandler.GetWebhooks()))).Methods("GET")
+	r.Handle("/webhooks", auth(can(models.PermWebhookManage)(appHandler.CreateWebhook()))).Methods("POST")
+	r.Handle("/webhooks/{webhook_id}", auth(can(models.PermWebhookManage)(appHandler.GetWebhook()))).Methods("GET")
+	r.Handle("/webhooks/{webhook_id}", auth(can(models.PermWebhookManage)(appHandler.UpdateWebhook()))).Methods("PUT")
+	r.Handle("/webhooks/{webhook_id}", auth(can(models.PermWebhookManage)(appHandler.DeleteWebhook()))).Methods("DELETE")
+	r.Handle("/webhooks/{webhook_id}/deliveries", auth(can(models.PermWebhookManage)(appHandler.GetWebhookDeliveries()))).Methods("GET")
+	r.Handle("/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver", auth(can(models.PermWebhookManage)(appHandler.RedeliverWebhook()))).Methods("POST")
+	r.Handle("/friends", auth(can(models.PermFriendshipManage)(appHandler.CreateFriendship()))).Methods("POST")
+	r.Handle("/friends/accept", auth(can(models.PermFriendshipManage)(appHandler.AcceptFriendRequest()))).Methods("POST")
+	r.Handle("/friends/reject", auth(can(models.PermFriendshipManage)(appHandler.RejectFriendRequest()))).Methods("POST")
 
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
-	log.Fatal(http.ListenAndServe(":8080", r))
+	log.Fatal(http.ListenAndServe(cfg.Server.Addr, r))
+}
+
+func newBlobStore(cfg config.AttachmentsConfig) (blob.Store, error) {
+	if cfg.Storage == "s3" {
+		return &blob.S3Store{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKeyID,
+			SecretKey: cfg.S3.SecretAccessKey,
+			PathStyle: cfg.S3.PathStyle,
+			Client:    &http.Client{Timeout: 5 * time.Minute},
+		}, nil
+	}
+	return blob.NewLocalStore(cfg.Dir)
+}
+
+// newNotifier builds the notification service, with the email channel only
+// when an SMTP host is configured.
+func newNotifier(cfg config.NotificationsConfig, notifications store.NotificationStore, users store.UserStore) (*notify.Service, error) {
+	templates, err := notify.LoadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	notifier := notify.NewService(notifications, users, templates, cfg.DefaultChannels)
+	notifier.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.SMTP.Host != "" {
+		notifier.Mailer = &notify.Mailer{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+			Timeout:  cfg.SMTP.Timeout,
+		}
+	}
+	return notifier, nil
+}
+
+// purgeOrphanedAttachments deletes the files of purged tasks. A row is only
+// removed after its blob, so a failed run is retried on the next one.
+func purgeOrphanedAttachments(ctx context.Context, attachments store.AttachmentStore, blobs blob.Store) error {
+	orphans, err := attachments.ListOrphanedAttachments()
+	if err != nil {
+		return err
+	}
+	for _, a := range orphans {
+		if err := blobs.Delete(ctx, a.StorageKey); err != nil {
+			return err
+		}
+		if err := attachments.DeleteAttachment(a.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+	}
+	if len(orphans) > 0 {
+		log.Printf("Deleted %d attachments of purged tasks", len(orphans))
+	}
+	return nil
 }
@@ -2,40 +2,73 @@ package middleware
 
 import (
 	"context"
+	"log"
 	"net/http"
-	"os"
 	"task-management-system/models"
+	"task-management-system/store"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET_KEY"))
+// JWTMiddleware rejects tokens that are invalid, carry no jti or are on the
+// revocation list, and puts the user ID, role and claims into the context.
+func JWTMiddleware(jwtKey []byte, revocations store.RevocationStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := r.Header.Get("Authorization")
+			if tokenString == "" {
+				http.Error(w, "Missing token", http.StatusUnauthorized)
+				return
+			}
 
-func JWTMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := r.Header.Get("Authorization")
-		if tokenString == "" {
-			http.Error(w, "Missing token", http.StatusUnauthorized)
-			return
-		}
+			// "Bearer " ön ekini kaldır
+			if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+				tokenString = tokenString[7:]
+			}
 
-		// "Bearer " ön ekini kaldır
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
-		}
+			claims := &models.Claims{}
+			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+				return jwtKey, nil
+			})
 
-		claims := &models.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+			if err != nil || !token.Valid || claims.Id == "" {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			revoked, err := revocations.IsTokenRevoked(claims.Id, claims.UserID, time.Unix(claims.IssuedAt, 0))
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				log.Println("Revocation check error: ", err)
+				return
+			}
+			if revoked {
+				http.Error(w, "Token revoked", http.StatusUnauthorized)
+				return
+			}
 
-		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "role", claims.Role)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+			ctx = context.WithValue(ctx, "role", claims.Role)
+			ctx = context.WithValue(ctx, "claims", claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// CookieToken lets the token cookie set at login stand in for a missing
+// Authorization header. Browsers cannot set headers on EventSource
+// connections; it is only meant for read-only routes, cookies are sent along
+// with cross-site requests too.
+func CookieToken(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				if cookie, err := r.Cookie(name); err == nil && cookie.Value != "" {
+					r.Header.Set("Authorization", "Bearer "+cookie.Value)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"task-management-system/store"
+)
+
+// RequirePermission lets the request through when the role in the context
+// has at least one of the given permissions. The role's permissions are put
+// into the context as "permissions" (map[string]bool) for handlers that
+// distinguish e.g. task:update:any from task:update:own.
+func RequirePermission(roles store.RoleStore, permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			roleName, _ := r.Context().Value("role").(string)
+			role, err := roles.GetRole(roleName)
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				log.Println("Role lookup error: ", err)
+				return
+			}
+
+			granted := make(map[string]bool, len(role.Permissions))
+			for _, p := range role.Permissions {
+				granted[p] = true
+			}
+
+			for _, p := range permissions {
+				if granted[p] {
+					ctx := context.WithValue(r.Context(), "permissions", granted)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
@@ -1,20 +0,0 @@
-package middleware
-
-import (
-	"net/http"
-)
-
-func RoleMiddleware(allowedRoles ...string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			role := r.Context().Value("role").(string)
-			for _, allowedRole := range allowedRoles {
-				if role == allowedRole {
-					next.ServeHTTP(w, r)
-					return
-				}
-			}
-			http.Error(w, "Forbidden", http.StatusForbidden)
-		})
-	}
-}
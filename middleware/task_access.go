@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"task-management-system/authz"
+	"task-management-system/store"
+
+	"github.com/gorilla/mux"
+)
+
+// RequireTaskAccess loads the task named by the {task_id} route variable and
+// lets the request through when the user may perform at least one of the
+// actions on it. The task is put into the context as "task". It must run
+// after RequirePermission, which provides the permissions. Tasks in the trash
+// are reported as not found.
+func RequireTaskAccess(tasks store.TaskStore, actions ...authz.Action) func(http.Handler) http.Handler {
+	return requireTaskAccess(tasks, false, actions)
+}
+
+// RequireTrashedTaskAccess is RequireTaskAccess for the routes that work on
+// tasks in the trash, e.g. restore. Live tasks are let through as well.
+func RequireTrashedTaskAccess(tasks store.TaskStore, actions ...authz.Action) func(http.Handler) http.Handler {
+	return requireTaskAccess(tasks, true, actions)
+}
+
+func requireTaskAccess(tasks store.TaskStore, includeTrash bool, actions []authz.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			taskID, err := strconv.Atoi(mux.Vars(r)["task_id"])
+			if err != nil {
+				http.Error(w, "Invalid task ID", http.StatusBadRequest)
+				return
+			}
+
+			task, err := tasks.GetTask(taskID)
+			if errors.Is(err, store.ErrNotFound) || (err == nil && task.DeletedAt != nil && !includeTrash) {
+				http.Error(w, "Task not found", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				log.Println("Task lookup error: ", err)
+				return
+			}
+
+			actor := authz.ActorFromContext(r.Context())
+			for _, action := range actions {
+				if authz.Can(actor, action, task) {
+					ctx := context.WithValue(r.Context(), "task", task)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
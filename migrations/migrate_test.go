@@ -0,0 +1,211 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	// the migrator logs every migration it applies
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func migration(version int, name, up, down string) Migration {
+	sum := sha256.Sum256([]byte(up))
+	return Migration{Version: version, Name: name, Up: up, Down: down, Checksum: hex.EncodeToString(sum[:])}
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		migration(1, "notes", "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);", "DROP TABLE notes;"),
+		migration(2, "tags", "CREATE TABLE tags (id INTEGER PRIMARY KEY);\nCREATE INDEX tags_id ON tags (id);", "DROP TABLE tags;"),
+	}
+}
+
+func appliedVersions(t *testing.T, m *Migrator) []int {
+	t.Helper()
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := []int{}
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+func TestUpDown(t *testing.T) {
+	m := &Migrator{DB: openDB(t), Migrations: testMigrations()}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("applied %v after Up", got)
+	}
+	// Up is a no-op once everything is applied
+	if err := m.Up(); err != nil {
+		t.Errorf("second Up: %v", err)
+	}
+
+	if err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("applied %v after Down(1)", got)
+	}
+	if _, err := m.DB.Exec("INSERT INTO tags (id) VALUES (1)"); err == nil {
+		t.Error("the tags table still exists after Down(1)")
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("applied %v after Up again", got)
+	}
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	m := &Migrator{DB: openDB(t), Migrations: []Migration{
+		migration(1, "notes", "CREATE TABLE notes (id INTEGER PRIMARY KEY);", ""),
+		migration(2, "broken", "CREATE TABLE broken (id INTEGER PRIMARY KEY);\nINSERT INTO missing VALUES (1);", ""),
+	}}
+	err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "migration 2_broken") {
+		t.Fatalf("Up = %v, want the error of 2_broken", err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("applied %v, want only 1", got)
+	}
+	if _, err := m.DB.Exec("INSERT INTO broken (id) VALUES (1)"); err == nil {
+		t.Error("the statements of the failed migration were kept")
+	}
+}
+
+func TestRefusesModifiedMigration(t *testing.T) {
+	db := openDB(t)
+	if err := (&Migrator{DB: db, Migrations: testMigrations()}).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file of an applied migration was edited afterwards
+	modified := testMigrations()
+	modified[0] = migration(1, "notes", "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT, title TEXT);", "DROP TABLE notes;")
+	modified = append(modified, migration(3, "later", "CREATE TABLE later (id INTEGER PRIMARY KEY);", ""))
+	m := &Migrator{DB: db, Migrations: modified}
+
+	for name, run := range map[string]func() error{
+		"Up":     m.Up,
+		"Down":   func() error { return m.Down(1) },
+		"Status": func() error { _, err := m.Status(); return err },
+	} {
+		if err := run(); err == nil || !strings.Contains(err.Error(), "1_notes was modified after it was applied (checksum mismatch)") {
+			t.Errorf("%s = %v, want the checksum mismatch", name, err)
+		}
+	}
+	// nothing after the mismatch was applied
+	if _, err := db.Exec("INSERT INTO later (id) VALUES (1)"); err == nil {
+		t.Error("Up applied migration 3 despite the mismatch")
+	}
+}
+
+func TestRefusesUnknownMigration(t *testing.T) {
+	db := openDB(t)
+	if err := (&Migrator{DB: db, Migrations: testMigrations()}).Up(); err != nil {
+		t.Fatal(err)
+	}
+	// an older binary that only knows the first migration
+	m := &Migrator{DB: db, Migrations: testMigrations()[:1]}
+	if err := m.Up(); err == nil || !strings.Contains(err.Error(), "migration 2 applied which is not known") {
+		t.Errorf("Up = %v, want the unknown migration refused", err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"single", "CREATE TABLE a (id INT);", []string{"CREATE TABLE a (id INT)"}},
+		{"multi line", "CREATE TABLE a (\n  id INT\n);\nDROP TABLE b;\n", []string{"CREATE TABLE a (\n  id INT\n)", "DROP TABLE b"}},
+		{"comments", "-- the notes\nCREATE TABLE a (id INT); \n  -- done;\n", []string{"CREATE TABLE a (id INT)"}},
+		{"semicolon inside a line", "INSERT INTO a VALUES ('x;y');", []string{"INSERT INTO a VALUES ('x;y')"}},
+		{"no trailing semicolon", "DROP TABLE a;\nDROP TABLE b", []string{"DROP TABLE a", "DROP TABLE b"}},
+		{"empty statements", ";\n\n;\n", nil},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitStatements = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// The embedded SQLite migrations apply, roll back completely and apply again.
+func TestEmbeddedSQLiteMigrations(t *testing.T) {
+	m, err := New(openDB(t), "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Down(len(m.Migrations)); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t, m); len(got) != 0 {
+		t.Errorf("applied %v after rolling back everything", got)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMatchesDrivers(t *testing.T) {
+	mysql, err := load("mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := load("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mysql) != len(sqlite) {
+		t.Fatalf("%d mysql and %d sqlite migrations", len(mysql), len(sqlite))
+	}
+	for i := range mysql {
+		if mysql[i].Version != sqlite[i].Version || mysql[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d_%s has no sqlite counterpart, got %d_%s", mysql[i].Version, mysql[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+		if mysql[i].Down == "" || sqlite[i].Down == "" {
+			t.Errorf("migration %d_%s has no down file", mysql[i].Version, mysql[i].Name)
+		}
+	}
+	if _, err := load("postgres"); err == nil {
+		t.Error("load of an unknown driver succeeded")
+	}
+}
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    email VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    start_date DATETIME NOT NULL,
+    due_date DATETIME NOT NULL,
+    user_id INT NOT NULL,
+    assigned_to INT NOT NULL,
+    INDEX idx_tasks_user_id (user_id),
+    INDEX idx_tasks_assigned_to (assigned_to)
+);
+
+CREATE TABLE IF NOT EXISTS friendships (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    friend_id INT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    INDEX idx_friendships_users (user_id, friend_id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) NOT NULL PRIMARY KEY,
+    user_id INT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NOT NULL,
+    INDEX idx_revoked_tokens_expires_at (expires_at)
+);
+
+CREATE TABLE session_revocations (
+    user_id INT NOT NULL PRIMARY KEY,
+    revoked_at DATETIME NOT NULL
+);
@@ -0,0 +1,22 @@
+CREATE TABLE roles (
+    name VARCHAR(50) NOT NULL PRIMARY KEY
+);
+
+CREATE TABLE role_permissions (
+    role VARCHAR(50) NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role, permission),
+    FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name) VALUES ('admin'), ('user');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'task:create'),
+    ('admin', 'task:read'),
+    ('admin', 'task:update:any'),
+    ('admin', 'task:delete:any'),
+    ('admin', 'friendship:manage'),
+    ('admin', 'user:admin'),
+    ('user', 'task:read'),
+    ('user', 'friendship:manage');
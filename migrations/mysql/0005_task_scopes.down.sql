@@ -0,0 +1,6 @@
+DELETE FROM role_permissions WHERE role IN ('admin', 'user')
+    AND permission IN ('task:update:own', 'task:delete:own', 'task:transition', 'task:comment');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'task:update:any'),
+    ('admin', 'task:delete:any');
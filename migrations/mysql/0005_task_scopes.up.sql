@@ -0,0 +1,10 @@
+-- admins only manage the tasks they created, assignees may change status and comment
+DELETE FROM role_permissions WHERE role = 'admin' AND permission IN ('task:update:any', 'task:delete:any');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'task:update:own'),
+    ('admin', 'task:delete:own'),
+    ('admin', 'task:transition'),
+    ('admin', 'task:comment'),
+    ('user', 'task:transition'),
+    ('user', 'task:comment');
@@ -0,0 +1,11 @@
+CREATE TABLE task_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_id INT NOT NULL,
+    actor_id INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_task_history_task (task_id, id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE tasks
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_tasks_deleted_at (deleted_at);
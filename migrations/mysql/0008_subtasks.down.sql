@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+    DROP INDEX idx_tasks_parent_id,
+    DROP COLUMN parent_id;
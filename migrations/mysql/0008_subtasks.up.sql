@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+    ADD COLUMN parent_id INT NULL,
+    ADD INDEX idx_tasks_parent_id (parent_id);
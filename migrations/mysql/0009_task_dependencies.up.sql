@@ -0,0 +1,7 @@
+CREATE TABLE task_dependencies (
+    task_id INT NOT NULL,
+    blocked_by INT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (task_id, blocked_by),
+    INDEX idx_task_dependencies_blocked_by (blocked_by)
+);
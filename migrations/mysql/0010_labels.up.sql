@@ -0,0 +1,14 @@
+CREATE TABLE labels (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    owner_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    UNIQUE KEY uq_labels_owner_name (owner_id, name)
+);
+
+CREATE TABLE task_labels (
+    task_id INT NOT NULL,
+    label_id INT NOT NULL,
+    PRIMARY KEY (task_id, label_id),
+    INDEX idx_task_labels_label (label_id)
+);
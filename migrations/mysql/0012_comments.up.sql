@@ -0,0 +1,29 @@
+CREATE TABLE comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_id INT NOT NULL,
+    author_id INT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NULL,
+    INDEX idx_comments_task (task_id, id)
+);
+
+CREATE TABLE comment_edits (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    comment_id INT NOT NULL,
+    body TEXT NOT NULL,
+    edited_at DATETIME NOT NULL,
+    INDEX idx_comment_edits_comment (comment_id, id)
+);
+
+CREATE TABLE notifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    task_id INT NOT NULL,
+    actor_id INT NOT NULL,
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    read_at DATETIME NULL,
+    INDEX idx_notifications_user (user_id, id)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE task_recurrences (
+    task_id INT PRIMARY KEY,
+    rule VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL,
+    series_start DATETIME NOT NULL,
+    last_task_id INT NOT NULL,
+    next_start DATETIME NULL,
+    created_at DATETIME NOT NULL
+);
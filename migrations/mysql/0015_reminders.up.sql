@@ -0,0 +1,18 @@
+ALTER TABLE tasks ADD COLUMN overdue_at DATETIME NULL;
+
+CREATE TABLE reminder_preferences (
+    user_id INT PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    lead_hours VARCHAR(100) NOT NULL,
+    escalations BOOLEAN NOT NULL
+);
+
+CREATE TABLE reminder_deliveries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_id INT NOT NULL,
+    user_id INT NOT NULL,
+    kind VARCHAR(30) NOT NULL,
+    due_date DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_reminder_deliveries (task_id, user_id, kind, due_date)
+);
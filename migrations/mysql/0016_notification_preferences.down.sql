@@ -0,0 +1,2 @@
+DROP INDEX idx_notifications_unread ON notifications;
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,7 @@
+CREATE TABLE notification_preferences (
+    user_id INT NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    channels VARCHAR(100) NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+CREATE INDEX idx_notifications_unread ON notifications (user_id, read_at);
@@ -0,0 +1,29 @@
+CREATE TABLE webhooks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events VARCHAR(2048) NOT NULL,
+    active BOOLEAN NOT NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_webhooks_user (user_id)
+);
+
+CREATE TABLE webhook_deliveries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(30) NOT NULL,
+    attempts INT NOT NULL,
+    response_status INT NOT NULL,
+    last_error VARCHAR(1000) NOT NULL,
+    next_attempt_at DATETIME NULL,
+    last_attempt_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_webhook_deliveries_webhook (webhook_id, id),
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at)
+);
+
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'webhook:manage');
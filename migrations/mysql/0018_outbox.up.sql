@@ -0,0 +1,9 @@
+CREATE TABLE outbox_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(30) NOT NULL,
+    actor_id INT NOT NULL,
+    data MEDIUMTEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    published_at DATETIME NULL,
+    INDEX idx_outbox_events_published (published_at, id)
+);
@@ -0,0 +1,12 @@
+ALTER TABLE webhook_deliveries
+    DROP INDEX uq_webhook_deliveries_event;
+
+ALTER TABLE notifications
+    DROP INDEX uq_notifications_event,
+    DROP COLUMN event_id;
+
+ALTER TABLE outbox_events
+    DROP COLUMN failed_at,
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN last_error,
+    DROP COLUMN attempts;
@@ -0,0 +1,15 @@
+ALTER TABLE outbox_events
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    ADD COLUMN next_attempt_at DATETIME NULL,
+    ADD COLUMN failed_at DATETIME NULL;
+
+ALTER TABLE notifications
+    ADD COLUMN event_id BIGINT NULL,
+    ADD UNIQUE INDEX uq_notifications_event (user_id, event_id);
+
+-- duplicates left by events published more than once
+DELETE d FROM webhook_deliveries d
+    JOIN webhook_deliveries e ON e.webhook_id = d.webhook_id AND e.event_id = d.event_id AND e.id < d.id;
+ALTER TABLE webhook_deliveries
+    ADD UNIQUE INDEX uq_webhook_deliveries_event (webhook_id, event_id);
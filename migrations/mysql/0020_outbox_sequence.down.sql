@@ -0,0 +1,3 @@
+ALTER TABLE outbox_events
+    DROP INDEX uq_outbox_events_seq,
+    DROP COLUMN seq;
@@ -0,0 +1,6 @@
+ALTER TABLE outbox_events
+    ADD COLUMN seq BIGINT NULL,
+    ADD UNIQUE INDEX uq_outbox_events_seq (seq);
+
+-- stream clients resume from the IDs of the events published so far
+UPDATE outbox_events SET seq = id WHERE published_at IS NOT NULL;
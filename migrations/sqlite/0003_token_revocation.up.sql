@@ -0,0 +1,12 @@
+CREATE TABLE revoked_tokens (
+    jti TEXT NOT NULL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NOT NULL
+);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE session_revocations (
+    user_id INTEGER NOT NULL PRIMARY KEY,
+    revoked_at DATETIME NOT NULL
+);
@@ -0,0 +1,21 @@
+CREATE TABLE roles (
+    name TEXT NOT NULL PRIMARY KEY
+);
+
+CREATE TABLE role_permissions (
+    role TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name) VALUES ('admin'), ('user');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'task:create'),
+    ('admin', 'task:read'),
+    ('admin', 'task:update:any'),
+    ('admin', 'task:delete:any'),
+    ('admin', 'friendship:manage'),
+    ('admin', 'user:admin'),
+    ('user', 'task:read'),
+    ('user', 'friendship:manage');
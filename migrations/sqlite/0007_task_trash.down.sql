@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+ALTER TABLE tasks DROP COLUMN deleted_by;
+ALTER TABLE tasks DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE tasks ADD COLUMN deleted_by INTEGER NULL;
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at);
@@ -0,0 +1,7 @@
+CREATE TABLE task_dependencies (
+    task_id INTEGER NOT NULL,
+    blocked_by INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (task_id, blocked_by)
+);
+CREATE INDEX idx_task_dependencies_blocked_by ON task_dependencies (blocked_by);
@@ -0,0 +1,14 @@
+CREATE TABLE labels (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL,
+    UNIQUE (owner_id, name)
+);
+
+CREATE TABLE task_labels (
+    task_id INTEGER NOT NULL,
+    label_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, label_id)
+);
+CREATE INDEX idx_task_labels_label ON task_labels (label_id);
@@ -0,0 +1,29 @@
+CREATE TABLE comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NULL
+);
+CREATE INDEX idx_comments_task ON comments (task_id, id);
+
+CREATE TABLE comment_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    edited_at DATETIME NOT NULL
+);
+CREATE INDEX idx_comment_edits_comment ON comment_edits (comment_id, id);
+
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    task_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    message TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    read_at DATETIME NULL
+);
+CREATE INDEX idx_notifications_user ON notifications (user_id, id);
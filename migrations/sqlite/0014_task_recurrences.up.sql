@@ -0,0 +1,9 @@
+CREATE TABLE task_recurrences (
+    task_id INTEGER PRIMARY KEY,
+    rule TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    series_start DATETIME NOT NULL,
+    last_task_id INTEGER NOT NULL,
+    next_start DATETIME NULL,
+    created_at DATETIME NOT NULL
+);
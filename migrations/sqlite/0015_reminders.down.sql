@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reminder_deliveries;
+DROP TABLE IF EXISTS reminder_preferences;
+ALTER TABLE tasks DROP COLUMN overdue_at;
@@ -0,0 +1,18 @@
+ALTER TABLE tasks ADD COLUMN overdue_at DATETIME NULL;
+
+CREATE TABLE reminder_preferences (
+    user_id INTEGER PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    lead_hours TEXT NOT NULL,
+    escalations BOOLEAN NOT NULL
+);
+
+CREATE TABLE reminder_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    due_date DATETIME NOT NULL,
+    created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX uq_reminder_deliveries ON reminder_deliveries (task_id, user_id, kind, due_date);
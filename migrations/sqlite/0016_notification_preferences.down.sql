@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP TABLE IF EXISTS notification_preferences;
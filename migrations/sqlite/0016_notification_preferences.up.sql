@@ -0,0 +1,7 @@
+CREATE TABLE notification_preferences (
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    channels TEXT NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+CREATE INDEX idx_notifications_unread ON notifications (user_id, read_at);
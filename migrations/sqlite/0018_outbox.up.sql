@@ -0,0 +1,9 @@
+CREATE TABLE outbox_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    actor_id INTEGER NOT NULL,
+    data TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    published_at DATETIME NULL
+);
+CREATE INDEX idx_outbox_events_published ON outbox_events (published_at, id);
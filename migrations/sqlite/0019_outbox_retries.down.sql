@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS uq_webhook_deliveries_event;
+
+DROP INDEX IF EXISTS uq_notifications_event;
+ALTER TABLE notifications DROP COLUMN event_id;
+
+ALTER TABLE outbox_events DROP COLUMN failed_at;
+ALTER TABLE outbox_events DROP COLUMN next_attempt_at;
+ALTER TABLE outbox_events DROP COLUMN last_error;
+ALTER TABLE outbox_events DROP COLUMN attempts;
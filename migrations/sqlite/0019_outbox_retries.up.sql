@@ -0,0 +1,11 @@
+ALTER TABLE outbox_events ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE outbox_events ADD COLUMN last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE outbox_events ADD COLUMN next_attempt_at DATETIME NULL;
+ALTER TABLE outbox_events ADD COLUMN failed_at DATETIME NULL;
+
+ALTER TABLE notifications ADD COLUMN event_id INTEGER NULL;
+CREATE UNIQUE INDEX uq_notifications_event ON notifications (user_id, event_id);
+
+-- duplicates left by events published more than once
+DELETE FROM webhook_deliveries WHERE id NOT IN (SELECT MIN(id) FROM webhook_deliveries GROUP BY webhook_id, event_id);
+CREATE UNIQUE INDEX uq_webhook_deliveries_event ON webhook_deliveries (webhook_id, event_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_outbox_events_seq;
+ALTER TABLE outbox_events DROP COLUMN seq;
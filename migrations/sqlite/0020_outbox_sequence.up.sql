@@ -0,0 +1,5 @@
+ALTER TABLE outbox_events ADD COLUMN seq INTEGER NULL;
+CREATE UNIQUE INDEX uq_outbox_events_seq ON outbox_events (seq);
+
+-- stream clients resume from the IDs of the events published so far
+UPDATE outbox_events SET seq = id WHERE published_at IS NOT NULL;
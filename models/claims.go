@@ -2,6 +2,8 @@ package models
 
 import "github.com/dgrijalva/jwt-go"
 
+// Claims of an access token. StandardClaims.Id is the JWT ID (jti) that
+// logout and the revocation list refer to.
 type Claims struct {
 	Username string `json:"username"`
 	UserID   int    `json:"userID"`
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID        int          `json:"id"`
+	TaskID    int          `json:"task_id"`
+	AuthorID  int          `json:"author_id"`
+	Author    *UserSummary `json:"author,omitempty"`
+	Body      string       `json:"body"`
+	CreatedAt time.Time    `json:"created_at"`
+	// UpdatedAt is set once the comment has been edited.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// CommentEdit keeps the text a comment had before an edit.
+type CommentEdit struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	Body      string    `json:"body"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// TaskDependency means that TaskID cannot start until BlockedBy is closed.
+type TaskDependency struct {
+	TaskID    int `json:"task_id"`
+	BlockedBy int `json:"blocked_by"`
+}
+
+type DependencyRequest struct {
+	BlockedBy int `json:"blocked_by"`
+}
+
+// DependencyNode is a task of a dependency graph. Title and status are left
+// out for tasks the user cannot see.
+type DependencyNode struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title,omitempty"`
+	Status string `json:"status,omitempty"`
+	Open   bool   `json:"open"`
+}
+
+// DependencyGraph holds every task that blocks TaskID or is blocked by it,
+// directly or through other tasks.
+type DependencyGraph struct {
+	TaskID int              `json:"task_id"`
+	Nodes  []DependencyNode `json:"nodes"`
+	Edges  []TaskDependency `json:"edges"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventTaskCreated         = "task.created"
+	EventTaskUpdated         = "task.updated"
+	EventTaskDeleted         = "task.deleted"
+	EventTaskRestored        = "task.restored"
+	EventCommentCreated      = "comment.created"
+	EventCommentUpdated      = "comment.updated"
+	EventCommentDeleted      = "comment.deleted"
+	EventFriendshipRequested = "friendship.requested"
+	EventFriendshipAccepted  = "friendship.accepted"
+)
+
+var EventTypes = []string{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskDeleted,
+	EventTaskRestored,
+	EventCommentCreated,
+	EventCommentUpdated,
+	EventCommentDeleted,
+	EventFriendshipRequested,
+	EventFriendshipAccepted,
+}
+
+func IsEventType(t string) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is something that happened to a task, comment or friendship. Data
+// holds a TaskEvent for task.* events, a CommentEvent for comment.* events
+// and a Friendship for friendship.* events. Seq
+// is the position of the event in publish order. IDs are taken when the
+// change is saved and may be published out of order, e.g. when an earlier
+// transaction commits later.
+type Event struct {
+	ID        int64           `json:"id"`
+	Seq       int64           `json:"seq"`
+	Type      string          `json:"type"`
+	ActorID   int             `json:"actor_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data" swaggertype:"object"`
+	// Attempts is the number of failed publishes of the event.
+	Attempts int `json:"-"`
+}
+
+// TaskEvent is the data of task events. Deleted tasks are sent as they were
+// before the deletion.
+type TaskEvent struct {
+	Task    Task          `json:"task"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// CommentEvent is the data of comment events. Deleted comments are sent as
+// they were before the deletion, PreviousBody is set for comment.updated.
+type CommentEvent struct {
+	Task         Task    `json:"task"`
+	Comment      Comment `json:"comment"`
+	PreviousBody string  `json:"previous_body,omitempty"`
+}
+
+// FieldChange is a field changed by a task.updated event.
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// Label is a personal tag a user puts on tasks. Labels are only visible to
+// their owner.
+type Label struct {
+	ID      int    `json:"id"`
+	OwnerID int    `json:"owner_id"`
+	Name    string `json:"name"`
+	Color   string `json:"color"` // #rrggbb
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+const (
+	NotificationMention        = "mention"
+	NotificationReminder       = "reminder"
+	NotificationOverdue        = "overdue"
+	NotificationEscalation     = "escalation"
+	NotificationTaskAssigned   = "task_assigned"
+	NotificationFriendRequest  = "friend_request"
+	NotificationFriendAccepted = "friend_accepted"
+)
+
+var NotificationTypes = []string{
+	NotificationMention,
+	NotificationReminder,
+	NotificationOverdue,
+	NotificationEscalation,
+	NotificationTaskAssigned,
+	NotificationFriendRequest,
+	NotificationFriendAccepted,
+}
+
+// Channels a notification can be delivered over.
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+)
+
+var NotificationChannels = []string{ChannelInApp, ChannelEmail}
+
+func IsNotificationType(t string) bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+func IsNotificationChannel(c string) bool {
+	return c == ChannelInApp || c == ChannelEmail
+}
+
+// Notification is an entry of a user's inbox. TaskID is 0 for notifications
+// that are not about a task, e.g. friend requests. EventID is the event the
+// notification was created for, a user gets one notification per event.
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Type      string     `json:"type"`
+	TaskID    int        `json:"task_id,omitempty"`
+	EventID   int64      `json:"event_id,omitempty"`
+	ActorID   int        `json:"actor_id"`
+	Message   string     `json:"message"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationPreferences maps notification types to the channels they are
+// delivered over. Types without an entry use the configured defaults.
+type NotificationPreferences struct {
+	Channels map[string][]string `json:"channels"`
+}
@@ -0,0 +1,25 @@
+package models
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities lists the priorities from the least to the most urgent.
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// PriorityRank orders priorities, 0 is returned for unknown values.
+func PriorityRank(priority string) int {
+	for i, p := range Priorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func IsPriority(priority string) bool {
+	return PriorityRank(priority) != 0
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// TaskRecurrence repeats a task. The task is the template of the series and
+// its first occurrence; the next occurrence is created from it when the
+// latest one is completed or cancelled, or when NextStart arrives, whichever
+// comes first.
+type TaskRecurrence struct {
+	TaskID int `json:"task_id"`
+	// Rule is an RFC 5545 RRULE, see the recurrence package for the
+	// supported subset.
+	Rule string `json:"rule"`
+	// Timezone is the IANA time zone the rule is evaluated in, occurrences
+	// keep the local time of day of Start.
+	Timezone   string    `json:"timezone"`
+	Start      time.Time `json:"start"`
+	LastTaskID int       `json:"last_task_id"`
+	// NextStart is nil once the rule has no more occurrences.
+	NextStart *time.Time `json:"next_start"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type RecurrenceRequest struct {
+	Rule     string `json:"rule" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Timezone string `json:"timezone" example:"Europe/Istanbul"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// MaxReminderLeadHours is the earliest a reminder can be sent, 30 days
+// before the due date.
+const MaxReminderLeadHours = 720
+
+// ReminderPreferences control the due date reminders of a user. Users who
+// never saved theirs get the configured defaults.
+type ReminderPreferences struct {
+	UserID int `json:"-"`
+	// Enabled turns the reminders and overdue notices for tasks assigned
+	// to the user on or off.
+	Enabled bool `json:"enabled"`
+	// LeadHours lists how many hours before the due date to remind, e.g.
+	// [24, 1].
+	LeadHours []int `json:"lead_hours"`
+	// Escalations notifies the user when a task they created is still
+	// overdue after the grace period.
+	Escalations bool `json:"escalations"`
+}
+
+// ReminderDelivery records a reminder that was sent, so it is sent once per
+// task, user, kind and due date.
+type ReminderDelivery struct {
+	TaskID    int
+	UserID    int
+	Kind      string
+	DueDate   time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,52 @@
+package models
+
+// Permissions checked by the API. Roles are stored in the database as sets
+// of these strings.
+const (
+	PermTaskCreate       = "task:create"
+	PermTaskRead         = "task:read" // tasks the user created or is assigned to
+	PermTaskReadAny      = "task:read:any"
+	PermTaskUpdateAny    = "task:update:any"
+	PermTaskUpdateOwn    = "task:update:own"
+	PermTaskDeleteAny    = "task:delete:any"
+	PermTaskDeleteOwn    = "task:delete:own"
+	PermTaskTransition   = "task:transition" // change the status of tasks assigned to the user
+	PermTaskComment      = "task:comment"
+	PermFriendshipManage = "friendship:manage"
+	PermUserAdmin        = "user:admin"
+	PermWebhookManage    = "webhook:manage"
+)
+
+var Permissions = []string{
+	PermTaskCreate,
+	PermTaskRead,
+	PermTaskReadAny,
+	PermTaskUpdateAny,
+	PermTaskUpdateOwn,
+	PermTaskDeleteAny,
+	PermTaskDeleteOwn,
+	PermTaskTransition,
+	PermTaskComment,
+	PermFriendshipManage,
+	PermUserAdmin,
+	PermWebhookManage,
+}
+
+func IsPermission(p string) bool {
+	for _, known := range Permissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleAssignment is the body of PUT /users/{user_id}/role.
+type RoleAssignment struct {
+	Role string `json:"role"`
+}
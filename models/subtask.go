@@ -0,0 +1,13 @@
+package models
+
+type SubtaskRequest struct {
+	TaskID int `json:"task_id"`
+}
+
+// TaskProgress rolls the completion of the subtasks up to their parent. A
+// subtask with subtasks of its own counts with its own percentage.
+type TaskProgress struct {
+	Subtasks  int `json:"subtasks"`
+	Completed int `json:"completed"`
+	Percent   int `json:"percent"`
+}
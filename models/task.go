@@ -7,8 +7,27 @@ type Task struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Status      string    `json:"status"`
+	Priority    string    `json:"priority"`
 	StartDate   time.Time `json:"start_date"`
 	DueDate     time.Time `json:"due_date"`
 	UserID      int       `json:"user_id"`
 	AssignedTo  int       `json:"assigned_to"`
+	ParentID    *int      `json:"parent_id,omitempty"`
+	// DeletedAt and DeletedBy are set while the task is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *int       `json:"deleted_by,omitempty"`
+	// OverdueAt is set by the reminder scheduler when the due date passes
+	// while the task is open. Moving the due date clears it.
+	OverdueAt *time.Time `json:"overdue_at,omitempty"`
+}
+
+// TaskDetail is a task together with its related records.
+type TaskDetail struct {
+	Task
+	Creator  *UserSummary `json:"creator"`
+	Assignee *UserSummary `json:"assignee"`
+	// Progress is nil for tasks without subtasks.
+	Progress *TaskProgress `json:"progress,omitempty"`
+	// Labels are the labels of the requesting user on the task.
+	Labels []Label `json:"labels"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	HistoryCreated  = "created"
+	HistoryUpdated  = "updated"
+	HistoryDeleted  = "deleted"
+	HistoryRestored = "restored"
+)
+
+// TaskHistoryEntry records one changed field of a task. Deletions and
+// restores have a single entry without a field.
+type TaskHistoryEntry struct {
+	ID        int       `json:"id"`
+	TaskID    int       `json:"task_id"`
+	ActorID   int       `json:"actor_id"`
+	Action    string    `json:"action"`
+	Field     string    `json:"field,omitempty"`
+	OldValue  string    `json:"old_value,omitempty"`
+	NewValue  string    `json:"new_value,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
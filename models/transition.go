@@ -0,0 +1,5 @@
+package models
+
+type TransitionRequest struct {
+	To string `json:"to"`
+}
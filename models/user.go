@@ -7,3 +7,9 @@ type User struct {
 	Role     string `json:"role"` //admin - user
 	Email    string `json:"email"`
 }
+
+// UserSummary is the public part of a user shown next to related records.
+type UserSummary struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
@@ -1,8 +1,19 @@
 package models
 
+// UserStats counts every task assigned to the user, or only the tasks
+// without subtasks when requested with leaf_only.
 type UserStats struct {
-	UserID         int `json:"user_id"`
-	TotalTasks     int `json:"total_tasks"`
+	UserID     int `json:"user_id"`
+	TotalTasks int `json:"total_tasks"`
+	// CompletedTasks counts the tasks in a done status of the workflow,
+	// PendingTasks the tasks in its initial status.
 	CompletedTasks int `json:"completed_tasks"`
 	PendingTasks   int `json:"pending_tasks"`
+	// ByStatus has an entry for every status of the workflow.
+	ByStatus map[string]int `json:"by_status"`
+	// ByLabel has an entry for every label of the user.
+	ByLabel map[string]int `json:"by_label"`
+	// OpenByPriority counts the tasks that are not in a terminal status,
+	// with an entry for every priority.
+	OpenByPriority map[string]int `json:"open_by_priority"`
 }
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// email is a message received by fakeSMTP.
+type email struct {
+	From, To string
+	Subject  string
+	Body     string
+	Header   mail.Header
+}
+
+// fakeSMTP accepts messages without TLS or authentication and hands them
+// over on Received.
+type fakeSMTP struct {
+	t        *testing.T
+	ln       net.Listener
+	Received chan email
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeSMTP{t: t, ln: ln, Received: make(chan email, 10)}
+	go f.serve()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeSMTP) Port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		go f.session(conn)
+	}
+}
+
+func (f *fakeSMTP) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost ESMTP")
+	var from, to string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			from = strings.Trim(strings.TrimPrefix(cmd[len("MAIL"):], " FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			to = strings.Trim(strings.TrimPrefix(cmd[len("RCPT"):], " TO:"), "<>")
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			f.Received <- f.parse(from, to, data.String())
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (f *fakeSMTP) parse(from, to, data string) email {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		f.t.Errorf("invalid message: %v\n%s", err, data)
+		return email{}
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		f.t.Errorf("invalid subject: %v", err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		f.t.Errorf("invalid body: %v", err)
+	}
+	return email{From: from, To: to, Subject: subject, Body: string(body), Header: msg.Header}
+}
+
+// next waits for the next message.
+func (f *fakeSMTP) next(t *testing.T) email {
+	t.Helper()
+	select {
+	case e := <-f.Received:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was sent")
+		return email{}
+	}
+}
+
+func TestMailerSend(t *testing.T) {
+	srv := newFakeSMTP(t)
+	m := &Mailer{Host: "127.0.0.1", Port: srv.Port(), From: "Tasks <tasks@example.com>", Timeout: 5 * time.Second}
+
+	body := "Hi ayşe,\n\na line longer than seventy-six characters is soft wrapped by quoted-printable encoding\n"
+	if err := m.Send("Ayşe <ayse@example.com>", "Görev atandı", body); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	e := srv.next(t)
+	if e.From != "tasks@example.com" || e.To != "ayse@example.com" {
+		t.Errorf("envelope = %s -> %s", e.From, e.To)
+	}
+	if e.Subject != "Görev atandı" {
+		t.Errorf("Subject = %q", e.Subject)
+	}
+	if e.Body != strings.ReplaceAll(body, "\n", "\r\n") && e.Body != body {
+		t.Errorf("Body = %q, want %q", e.Body, body)
+	}
+	if got := e.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestMailerSendInvalidRecipient(t *testing.T) {
+	m := &Mailer{Host: "127.0.0.1", Port: 1, From: "tasks@example.com", Timeout: time.Second}
+	if err := m.Send("not an address", "subject", "body"); err == nil {
+		t.Error("Send to an invalid address succeeded")
+	}
+}
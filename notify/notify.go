@@ -0,0 +1,151 @@
+// Package notify delivers notifications to users over the channels they
+// chose: the in-app inbox and email.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"task-management-system/models"
+	"task-management-system/store"
+)
+
+// queueSize bounds the emails waiting to be sent. Notifications arriving
+// while the queue is full are still kept in the inbox, only the email is
+// dropped.
+const queueSize = 256
+
+type Service struct {
+	Store store.NotificationStore
+	Users store.UserStore
+	// Mailer sends the email channel, nil disables it.
+	Mailer    *Mailer
+	Templates *Templates
+	// DefaultChannels are used for the types a user has not chosen channels
+	// for.
+	DefaultChannels []string
+	// BaseURL is prepended to the links in emails, they are left out while
+	// it is empty.
+	BaseURL string
+
+	queue chan models.Notification
+}
+
+func NewService(notifications store.NotificationStore, users store.UserStore, templates *Templates, defaultChannels []string) *Service {
+	return &Service{
+		Store:           notifications,
+		Users:           users,
+		Templates:       templates,
+		DefaultChannels: defaultChannels,
+		queue:           make(chan models.Notification, queueSize),
+	}
+}
+
+// Preferences returns the channels of every notification type for the user,
+// with the defaults filled in.
+func (s *Service) Preferences(userID int) (models.NotificationPreferences, error) {
+	chosen, err := s.Store.GetNotificationPreferences(userID)
+	if err != nil {
+		return models.NotificationPreferences{}, err
+	}
+	prefs := models.NotificationPreferences{Channels: map[string][]string{}}
+	for _, t := range models.NotificationTypes {
+		channels, ok := chosen[t]
+		if !ok {
+			channels = s.DefaultChannels
+		}
+		prefs.Channels[t] = append([]string{}, channels...)
+	}
+	return prefs, nil
+}
+
+// Notify delivers n over the channels the recipient chose for its type. The
+// inbox entry is saved before Notify returns, emails are sent by Run. n.ID is
+// set when n is saved to the inbox. A notification of an event the inbox
+// already holds is not delivered again; recipients who turned the inbox off
+// for the type may get its email twice.
+func (s *Service) Notify(n *models.Notification) error {
+	chosen, err := s.Store.GetNotificationPreferences(n.UserID)
+	if err != nil {
+		return err
+	}
+	channels, ok := chosen[n.Type]
+	if !ok {
+		channels = s.DefaultChannels
+	}
+
+	var inApp, email bool
+	for _, channel := range channels {
+		inApp = inApp || channel == models.ChannelInApp
+		email = email || channel == models.ChannelEmail
+	}
+	// gelen kutusu önce, aynı olayın bildirimi e-postayı da tekrarlamasın
+	if inApp {
+		err := s.Store.CreateNotification(n)
+		if errors.Is(err, store.ErrConflict) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if email && s.Mailer != nil {
+		select {
+		case s.queue <- *n:
+		default:
+			log.Printf("Email queue is full, dropping %s email to user %d", n.Type, n.UserID)
+		}
+	}
+	return nil
+}
+
+// Run sends the queued emails until ctx is done.
+func (s *Service) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-s.queue:
+			if err := s.sendEmail(n); err != nil {
+				log.Printf("Error emailing %s notification to user %d: %v", n.Type, n.UserID, err)
+			}
+		}
+	}
+}
+
+func (s *Service) sendEmail(n models.Notification) error {
+	recipient, err := s.Users.GetUser(n.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if recipient.Email == "" {
+		return nil
+	}
+
+	data := Data{
+		Recipient:    models.UserSummary{ID: recipient.ID, Username: recipient.Username},
+		Notification: n,
+	}
+	if n.ActorID != 0 {
+		actor, err := s.Users.GetUser(n.ActorID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			data.Actor = &models.UserSummary{ID: actor.ID, Username: actor.Username}
+		}
+	}
+	if s.BaseURL != "" && n.TaskID != 0 {
+		data.Link = fmt.Sprintf("%s/tasks/%d", s.BaseURL, n.TaskID)
+	}
+
+	subject, body, err := s.Templates.Render(n.Type, data)
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(recipient.Email, subject, body)
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"task-management-system/models"
+	"task-management-system/store"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T, srv *fakeSMTP) (*Service, *store.MemoryUserStore) {
+	t.Helper()
+	templates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := store.NewMemoryUserStore()
+	s := NewService(store.NewMemoryNotificationStore(), users, templates, []string{models.ChannelInApp})
+	s.Mailer = &Mailer{Host: "127.0.0.1", Port: srv.Port(), From: "tasks@example.com", Timeout: 5 * time.Second}
+	s.BaseURL = "https://tasks.example.com"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.Run(ctx)
+	return s, users
+}
+
+func createUser(t *testing.T, users *store.MemoryUserStore, name, email string) models.User {
+	t.Helper()
+	u := models.User{Username: name, Password: "x", Role: "user", Email: email}
+	if err := users.CreateUser(&u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestNotifyEmailsChosenTypes(t *testing.T) {
+	srv := newFakeSMTP(t)
+	s, users := newTestService(t, srv)
+	alice := createUser(t, users, "alice", "alice@example.com")
+	bob := createUser(t, users, "bob", "bob@example.com")
+	if err := s.Store.SetNotificationPreferences(alice.ID, map[string][]string{
+		models.NotificationTaskAssigned:  {models.ChannelInApp, models.ChannelEmail},
+		models.NotificationFriendRequest: {models.ChannelInApp},
+		models.NotificationMention:       {},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifications := []models.Notification{
+		{UserID: alice.ID, Type: models.NotificationFriendRequest, ActorID: bob.ID, Message: "bob sent you a friend request"},
+		{UserID: alice.ID, Type: models.NotificationMention, TaskID: 3, ActorID: bob.ID, Message: "bob mentioned you"},
+		{UserID: alice.ID, Type: models.NotificationTaskAssigned, TaskID: 7, ActorID: bob.ID, Message: `You were assigned task #7 "Write tests"`},
+	}
+	for i := range notifications {
+		if err := s.Notify(&notifications[i]); err != nil {
+			t.Fatalf("Notify(%s): %v", notifications[i].Type, err)
+		}
+	}
+
+	// yalnızca e-posta seçilen tür gönderilir, Run sırayla çalışır
+	e := srv.next(t)
+	if e.To != "alice@example.com" {
+		t.Errorf("To = %q", e.To)
+	}
+	if want := "bob assigned you task #7"; e.Subject != want {
+		t.Errorf("Subject = %q, want %q", e.Subject, want)
+	}
+	for _, want := range []string{"Hi alice,", `You were assigned task #7 "Write tests"`, "Open the task: https://tasks.example.com/tasks/7"} {
+		if !strings.Contains(e.Body, want) {
+			t.Errorf("Body does not contain %q:\n%s", want, e.Body)
+		}
+	}
+	select {
+	case e := <-srv.Received:
+		t.Errorf("unexpected email %q", e.Subject)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	inbox, err := s.Store.ListNotifications(alice.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inbox) != 2 {
+		t.Errorf("inbox has %d notifications, want the friend request and the assignment", len(inbox))
+	}
+}
+
+func TestNotifyWithoutEmailAddress(t *testing.T) {
+	srv := newFakeSMTP(t)
+	s, users := newTestService(t, srv)
+	carol := createUser(t, users, "carol", "")
+	if err := s.Store.SetNotificationPreferences(carol.ID, map[string][]string{
+		models.NotificationReminder: {models.ChannelEmail},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Notify(&models.Notification{UserID: carol.ID, Type: models.NotificationReminder, TaskID: 1, Message: "due soon"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-srv.Received:
+		t.Errorf("unexpected email %q", e.Subject)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestNotifyEventOnce(t *testing.T) {
+	srv := newFakeSMTP(t)
+	s, users := newTestService(t, srv)
+	dave := createUser(t, users, "dave", "dave@example.com")
+	if err := s.Store.SetNotificationPreferences(dave.ID, map[string][]string{
+		models.NotificationTaskAssigned: {models.ChannelEmail, models.ChannelInApp},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		n := models.Notification{UserID: dave.ID, Type: models.NotificationTaskAssigned, TaskID: 2, EventID: 42, Message: "assigned"}
+		if err := s.Notify(&n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.next(t)
+	select {
+	case e := <-srv.Received:
+		t.Errorf("event emailed twice: %q", e.Subject)
+	case <-time.After(200 * time.Millisecond):
+	}
+	inbox, _ := s.Store.ListNotifications(dave.ID, false)
+	if len(inbox) != 1 {
+		t.Errorf("inbox has %d notifications, want 1", len(inbox))
+	}
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"task-management-system/models"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// Data is what email templates are executed with.
+type Data struct {
+	Recipient models.UserSummary
+	// Actor is the user who caused the notification, nil when there is none.
+	Actor        *models.UserSummary
+	Notification models.Notification
+	// Link points to the task in the web client, empty without a base URL or
+	// task.
+	Link string
+}
+
+// Templates holds one template per notification type, each defining a
+// "subject" and a "body". Types without a template use "default".
+type Templates struct {
+	byType map[string]*template.Template
+}
+
+// LoadTemplates parses the built-in templates and then the <type>.tmpl files
+// in dir, which replace the built-in template of their type. dir may be
+// empty.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{byType: map[string]*template.Template{}}
+	if err := t.parse(builtin, "templates"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := t.parse(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := t.byType["default"]; !ok {
+		return nil, fmt.Errorf("notification templates: default.tmpl is missing")
+	}
+	return t, nil
+}
+
+func (t *Templates) parse(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.tmpl")))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		tmpl, err := template.New(name).Option("missingkey=error").ParseFS(fsys, file)
+		if err != nil {
+			return fmt.Errorf("notification template %s: %w", file, err)
+		}
+		for _, part := range []string{"subject", "body"} {
+			if tmpl.Lookup(part) == nil {
+				return fmt.Errorf("notification template %s: %q is not defined", file, part)
+			}
+		}
+		t.byType[name] = tmpl
+	}
+	return nil
+}
+
+// Render returns the subject and body of the email for a notification of the
+// given type. The subject is folded into a single line.
+func (t *Templates) Render(notificationType string, data Data) (subject, body string, err error) {
+	tmpl, ok := t.byType[notificationType]
+	if !ok {
+		tmpl = t.byType["default"]
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+	buf.Reset()
+	if err := tmpl.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", err
+	}
+	return subject, strings.TrimLeft(buf.String(), "\n"), nil
+}
@@ -0,0 +1,356 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules
+// (RRULE) used to repeat tasks.
+//
+// Supported parts are FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, BYDAY (with
+// ordinals such as 1MO or -1FR for MONTHLY), BYMONTHDAY, COUNT and UNTIL.
+// The shortcuts daily, weekly and monthly stand for FREQ=DAILY, FREQ=WEEKLY
+// and FREQ=MONTHLY.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// time.LoadLocation must work on hosts without a zoneinfo database
+	_ "time/tzdata"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// Weekday is a BYDAY entry. N is 0 for every such day of the period, 1 for
+// the first, -1 for the last and so on.
+type Weekday struct {
+	N   int
+	Day time.Weekday
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []Weekday
+	ByMonthDay []int
+	// Count limits the number of occurrences, the start included. Zero means
+	// no limit.
+	Count int
+	// Until is the last possible occurrence. Unless UntilUTC is set it is a
+	// wall clock time in the time zone the rule is evaluated in.
+	Until    time.Time
+	UntilUTC bool
+}
+
+var dayNames = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// maxPeriods bounds the search for the next occurrence of rules that rarely
+// or never match, e.g. FREQ=MONTHLY;BYMONTHDAY=31;INTERVAL=12 started in June.
+const maxPeriods = 100000
+
+func Parse(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "daily", "weekly", "monthly":
+		s = "FREQ=" + strings.ToUpper(s)
+	}
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+
+	r := Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return r, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[name] {
+			return r, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				err = fmt.Errorf("unsupported FREQ %q, expected DAILY, WEEKLY or MONTHLY", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && (r.Interval < 1 || r.Interval > 1000) {
+				err = fmt.Errorf("INTERVAL must be between 1 and 1000")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("COUNT must be positive")
+			}
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				day, perr := parseWeekday(v)
+				if perr != nil {
+					err = perr
+					break
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				d, perr := strconv.Atoi(v)
+				if perr != nil || d == 0 || d < -31 || d > 31 {
+					err = fmt.Errorf("invalid BYMONTHDAY %q", v)
+					break
+				}
+				r.ByMonthDay = append(r.ByMonthDay, d)
+			}
+		case "WKST":
+			if value != "MO" {
+				err = fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+	return r, r.validate()
+}
+
+func (r *Rule) parseUntil(value string) error {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if layout == "20060102" {
+			// a date includes the whole day
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		r.Until, r.UntilUTC = t, strings.HasSuffix(layout, "Z")
+		return nil
+	}
+	return fmt.Errorf("invalid UNTIL %q, expected YYYYMMDD or YYYYMMDDTHHMMSSZ", value)
+}
+
+func parseWeekday(v string) (Weekday, error) {
+	if len(v) < 2 {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %q", v)
+	}
+	day, ok := dayNames[v[len(v)-2:]]
+	if !ok {
+		return Weekday{}, fmt.Errorf("invalid BYDAY %q", v)
+	}
+	w := Weekday{Day: day}
+	if prefix := v[:len(v)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return w, fmt.Errorf("invalid BYDAY %q", v)
+		}
+		w.N = n
+	}
+	return w, nil
+}
+
+func (r Rule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("COUNT and UNTIL cannot be used together")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return fmt.Errorf("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	if r.Freq != Monthly {
+		for _, d := range r.ByDay {
+			if d.N != 0 {
+				return fmt.Errorf("BYDAY ordinals are only supported with FREQ=MONTHLY")
+			}
+		}
+	}
+	return nil
+}
+
+// String returns the rule in RRULE form.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		layout := "20060102T150405"
+		if r.UntilUTC {
+			layout += "Z"
+		}
+		parts = append(parts, "UNTIL="+r.Until.Format(layout))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d Weekday) String() string {
+	name := strings.ToUpper(d.Day.String()[:2])
+	if d.N == 0 {
+		return name
+	}
+	return strconv.Itoa(d.N) + name
+}
+
+// Next returns the first occurrence after the given time for a series that
+// starts at start. Occurrences keep the wall clock time of start in loc, so a
+// daily 09:00 task stays at 09:00 across daylight saving changes. The start
+// is always the first occurrence. ok is false when the series has ended.
+func (r Rule) Next(start time.Time, loc *time.Location, after time.Time) (next time.Time, ok bool) {
+	start = start.In(loc)
+	until := r.Until
+	if !until.IsZero() && !r.UntilUTC {
+		until = time.Date(until.Year(), until.Month(), until.Day(), until.Hour(), until.Minute(), until.Second(), 0, loc)
+	}
+
+	n := 1
+	if start.After(after) {
+		return start, true
+	}
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.candidates(start, loc, period) {
+			if !t.After(start) {
+				continue
+			}
+			if !until.IsZero() && t.After(until) {
+				return time.Time{}, false
+			}
+			n++
+			if r.Count > 0 && n > r.Count {
+				return time.Time{}, false
+			}
+			if t.After(after) {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// candidates returns the occurrences of the given period, in order. Periods
+// are counted in units of INTERVAL days, weeks or months from start.
+func (r Rule) candidates(start time.Time, loc *time.Location, period int) []time.Time {
+	hour, min, sec := start.Clock()
+	at := func(d time.Time) time.Time {
+		return time.Date(d.Year(), d.Month(), d.Day(), hour, min, sec, 0, loc)
+	}
+	// günler UTC'de sayılır, yaz saati geçişleri gün hesabını bozmasın
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := first.AddDate(0, 0, period*r.Interval)
+		if r.matchesDay(day) && r.matchesMonthDay(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		monday := first.AddDate(0, 0, -((int(first.Weekday())+6)%7)+period*r.Interval*7)
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 {
+				if day.Weekday() == start.Weekday() {
+					days = append(days, day)
+				}
+			} else if r.matchesDay(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := time.Date(first.Year(), first.Month()+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		last := month.AddDate(0, 1, -1).Day()
+		for d := 1; d <= last; d++ {
+			day := month.AddDate(0, 0, d-1)
+			switch {
+			case len(r.ByDay) == 0 && len(r.ByMonthDay) == 0:
+				if d == start.Day() {
+					days = append(days, day)
+				}
+			case r.matchesMonthDay(day) && r.matchesNthDay(day, last):
+				days = append(days, day)
+			}
+		}
+	}
+
+	times := make([]time.Time, len(days))
+	for i, d := range days {
+		times[i] = at(d)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+func (r Rule) matchesDay(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Day == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNthDay checks BYDAY within a month of last days, ordinals included.
+func (r Rule) matchesNthDay(day time.Time, last int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Day != day.Weekday() {
+			continue
+		}
+		switch {
+		case d.N == 0,
+			d.N > 0 && (day.Day()-1)/7+1 == d.N,
+			d.N < 0 && (last-day.Day())/7+1 == -d.N:
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, d := range r.ByMonthDay {
+		if d == day.Day() || d < 0 && last+d+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// occurrences returns up to n occurrences of the series, the start included,
+// as local times.
+func occurrences(t *testing.T, rule string, start string, zone string, n int) []string {
+	t.Helper()
+	r, err := Parse(rule)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", rule, err)
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := time.ParseInLocation("2006-01-02 15:04", start, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	after := first.Add(-time.Second)
+	for len(got) < n {
+		next, ok := r.Next(first, loc, after)
+		if !ok {
+			break
+		}
+		got = append(got, next.In(loc).Format("2006-01-02 15:04"))
+		after = next
+	}
+	return got
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		zone  string
+		want  []string
+		// ends is set when the series has no occurrences after want.
+		ends bool
+	}{
+		{
+			name:  "daily",
+			rule:  "daily",
+			start: "2026-01-01 09:00",
+			want:  []string{"2026-01-01 09:00", "2026-01-02 09:00", "2026-01-03 09:00"},
+		},
+		{
+			name:  "daily interval",
+			rule:  "FREQ=DAILY;INTERVAL=3",
+			start: "2026-01-30 09:00",
+			want:  []string{"2026-01-30 09:00", "2026-02-02 09:00", "2026-02-05 09:00"},
+		},
+		{
+			name:  "daily by day",
+			rule:  "FREQ=DAILY;BYDAY=MO,FR",
+			start: "2026-01-05 09:00",
+			want:  []string{"2026-01-05 09:00", "2026-01-09 09:00", "2026-01-12 09:00"},
+		},
+		{
+			name:  "weekly",
+			rule:  "weekly",
+			start: "2026-01-07 09:00",
+			want:  []string{"2026-01-07 09:00", "2026-01-14 09:00", "2026-01-21 09:00"},
+		},
+		{
+			name:  "weekly by day",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			start: "2026-01-07 09:00",
+			want:  []string{"2026-01-07 09:00", "2026-01-09 09:00", "2026-01-12 09:00", "2026-01-14 09:00"},
+		},
+		{
+			name:  "weekly interval by day",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			start: "2026-01-06 09:00",
+			want:  []string{"2026-01-06 09:00", "2026-01-08 09:00", "2026-01-20 09:00", "2026-01-22 09:00"},
+		},
+		{
+			name:  "monthly skips months without the day",
+			rule:  "monthly",
+			start: "2026-01-31 09:00",
+			want:  []string{"2026-01-31 09:00", "2026-03-31 09:00", "2026-05-31 09:00"},
+		},
+		{
+			name:  "monthly last day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2026-01-31 09:00",
+			want:  []string{"2026-01-31 09:00", "2026-02-28 09:00", "2026-03-31 09:00", "2026-04-30 09:00"},
+		},
+		{
+			name:  "monthly leap day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=29",
+			start: "2028-01-29 09:00",
+			want:  []string{"2028-01-29 09:00", "2028-02-29 09:00", "2028-03-29 09:00"},
+		},
+		{
+			name:  "monthly day 29 outside leap years",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=29",
+			start: "2026-01-29 09:00",
+			want:  []string{"2026-01-29 09:00", "2026-03-29 09:00", "2026-04-29 09:00"},
+		},
+		{
+			name:  "monthly interval",
+			rule:  "FREQ=MONTHLY;INTERVAL=3",
+			start: "2026-11-15 09:00",
+			want:  []string{"2026-11-15 09:00", "2027-02-15 09:00", "2027-05-15 09:00"},
+		},
+		{
+			name:  "monthly first monday",
+			rule:  "FREQ=MONTHLY;BYDAY=1MO",
+			start: "2026-01-05 09:00",
+			want:  []string{"2026-01-05 09:00", "2026-02-02 09:00", "2026-03-02 09:00"},
+		},
+		{
+			name:  "monthly last friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: "2026-01-30 09:00",
+			want:  []string{"2026-01-30 09:00", "2026-02-27 09:00", "2026-03-27 09:00"},
+		},
+		{
+			name:  "count includes the start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: "2026-01-01 09:00",
+			want:  []string{"2026-01-01 09:00", "2026-01-02 09:00", "2026-01-03 09:00"},
+			ends:  true,
+		},
+		{
+			name:  "count with by day",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3",
+			start: "2026-01-05 09:00",
+			want:  []string{"2026-01-05 09:00", "2026-01-08 09:00", "2026-01-12 09:00"},
+			ends:  true,
+		},
+		{
+			name:  "until date includes the day",
+			rule:  "FREQ=DAILY;UNTIL=20260103",
+			start: "2026-01-01 09:00",
+			want:  []string{"2026-01-01 09:00", "2026-01-02 09:00", "2026-01-03 09:00"},
+			ends:  true,
+		},
+		{
+			name:  "until utc",
+			rule:  "FREQ=DAILY;UNTIL=20260103T075959Z",
+			start: "2026-01-01 09:00",
+			zone:  "Europe/Berlin",
+			want:  []string{"2026-01-01 09:00", "2026-01-02 09:00"},
+			ends:  true,
+		},
+		{
+			name:  "until local",
+			rule:  "FREQ=DAILY;UNTIL=20260103T090000",
+			start: "2026-01-01 09:00",
+			zone:  "Europe/Berlin",
+			want:  []string{"2026-01-01 09:00", "2026-01-02 09:00", "2026-01-03 09:00"},
+			ends:  true,
+		},
+		{
+			name:  "keeps the wall clock across daylight saving",
+			rule:  "daily",
+			start: "2026-03-28 09:00",
+			zone:  "Europe/Berlin",
+			want:  []string{"2026-03-28 09:00", "2026-03-29 09:00", "2026-03-30 09:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := tt.zone
+			if zone == "" {
+				zone = "UTC"
+			}
+			got := occurrences(t, tt.rule, tt.start, zone, len(tt.want)+1)
+			if !tt.ends && len(got) > len(tt.want) {
+				got = got[:len(tt.want)]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextSkipsPassedOccurrences(t *testing.T) {
+	r, err := Parse("FREQ=WEEKLY;BYDAY=MO,FR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	next, ok := r.Next(start, time.UTC, time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 2, 6, 9, 0, 0, 0, time.UTC); !ok || !next.Equal(want) {
+		t.Errorf("Next = %v, %v, want %v", next, ok, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{rule: "daily", want: "FREQ=DAILY"},
+		{rule: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{rule: "freq=monthly;byday=-1fr", want: "FREQ=MONTHLY;BYDAY=-1FR"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6", want: "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6"},
+		{rule: "FREQ=DAILY;UNTIL=20260131T120000Z", want: "FREQ=DAILY;UNTIL=20260131T120000Z"},
+		{rule: "FREQ=YEARLY", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=0", wantErr: true},
+		{rule: "FREQ=DAILY;COUNT=2;UNTIL=20260131", wantErr: true},
+		{rule: "FREQ=DAILY;UNTIL=tomorrow", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{rule: "FREQ=MONTHLY;BYDAY=6MO", wantErr: true},
+		{rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{rule: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s, want an error", tt.rule, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.rule, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
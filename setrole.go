@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"task-management-system/store"
+	"time"
+)
+
+// runSetRole changes the role of a user from the command line, e.g. to make
+// the first admin, since users cannot choose their role when registering.
+func runSetRole(users store.UserStore, roles store.RoleStore, revocations store.RevocationStore, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: set-role <username> <role>")
+	}
+	user, err := users.GetUserByUsername(args[0])
+	if err != nil {
+		return fmt.Errorf("user %q: %w", args[0], err)
+	}
+	if _, err := roles.GetRole(args[1]); err != nil {
+		return fmt.Errorf("role %q: %w", args[1], err)
+	}
+	if err := users.SetUserRole(user.ID, args[1]); err != nil {
+		return err
+	}
+	if err := revocations.RevokeUserSessions(user.ID, time.Now()); err != nil {
+		return err
+	}
+	fmt.Printf("%s is now %s\n", user.Username, args[1])
+	return nil
+}
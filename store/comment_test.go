@@ -0,0 +1,65 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"task-management-system/models"
+	"testing"
+)
+
+func commentEvent(typ string, comment *models.Comment) EventFunc {
+	return func() (models.Event, []models.TaskHistoryEntry, error) {
+		data, err := json.Marshal(models.CommentEvent{Comment: *comment})
+		return models.Event{Type: typ, ActorID: comment.AuthorID, CreatedAt: day, Data: data}, nil, err
+	}
+}
+
+func TestCommentStoreEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		comment := models.Comment{TaskID: 1, AuthorID: 2, Body: "first", CreatedAt: day}
+		if err := s.Comments.CreateComment(&comment, commentEvent(models.EventCommentCreated, &comment)); err != nil {
+			t.Fatal(err)
+		}
+		edited := comment
+		edited.Body = "second"
+		if err := s.Comments.UpdateComment(comment.ID, edited.Body, day, commentEvent(models.EventCommentUpdated, &edited)); err != nil {
+			t.Fatal(err)
+		}
+		missing := models.Comment{ID: comment.ID + 1}
+		if err := s.Comments.UpdateComment(missing.ID, "x", day, commentEvent(models.EventCommentUpdated, &missing)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateComment of a missing comment = %v, want ErrNotFound", err)
+		}
+		if err := s.Comments.DeleteComment(missing.ID, commentEvent(models.EventCommentDeleted, &missing)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteComment of a missing comment = %v, want ErrNotFound", err)
+		}
+		if err := s.Comments.DeleteComment(comment.ID, commentEvent(models.EventCommentDeleted, &edited)); err != nil {
+			t.Fatal(err)
+		}
+		if edits, _ := s.Comments.ListCommentEdits(comment.ID); len(edits) != 0 {
+			t.Errorf("the edits of the deleted comment are kept: %+v", edits)
+		}
+
+		// only the changes that were saved recorded an event, each with the
+		// comment as it was saved
+		events, err := s.Outbox.ListUnpublishedEvents(day, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, ev := range events {
+			var data models.CommentEvent
+			if err := json.Unmarshal(ev.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+			if data.Comment.ID != comment.ID {
+				t.Errorf("%s carries comment %d, want %d", ev.Type, data.Comment.ID, comment.ID)
+			}
+			got = append(got, ev.Type+" "+data.Comment.Body)
+		}
+		want := []string{"comment.created first", "comment.updated second", "comment.deleted second"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("events = %q, want %q", got, want)
+		}
+	})
+}
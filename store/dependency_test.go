@@ -0,0 +1,52 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTaskDependencyStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		// 1 waits for 2, 2 waits for 3
+		for _, d := range [][2]int{{1, 2}, {2, 3}} {
+			if err := s.Dependencies.AddTaskDependency(d[0], d[1], day); err != nil {
+				t.Fatalf("AddTaskDependency(%d, %d): %v", d[0], d[1], err)
+			}
+		}
+
+		tests := []struct {
+			taskID, blockedBy int
+			want              error
+		}{
+			{1, 2, ErrConflict},
+			{2, 1, ErrDependencyCycle},
+			{3, 1, ErrDependencyCycle},
+			{3, 2, ErrDependencyCycle},
+			{1, 3, nil},
+			{4, 1, nil},
+		}
+		for _, tt := range tests {
+			if err := s.Dependencies.AddTaskDependency(tt.taskID, tt.blockedBy, day); !errors.Is(err, tt.want) {
+				t.Errorf("AddTaskDependency(%d, %d) = %v, want %v", tt.taskID, tt.blockedBy, err, tt.want)
+			}
+		}
+
+		if got, _ := s.Dependencies.ListBlockers(1); !reflect.DeepEqual(got, []int{2, 3}) {
+			t.Errorf("ListBlockers(1) = %v, want [2 3]", got)
+		}
+		if got, _ := s.Dependencies.ListBlockedTasks(1); !reflect.DeepEqual(got, []int{4}) {
+			t.Errorf("ListBlockedTasks(1) = %v, want [4]", got)
+		}
+		if err := s.Dependencies.RemoveTaskDependency(2, 3); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Dependencies.RemoveTaskDependency(2, 3); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second RemoveTaskDependency = %v, want ErrNotFound", err)
+		}
+		// without 2 -> 3 the reverse edge no longer closes a cycle
+		if err := s.Dependencies.AddTaskDependency(3, 2, day); err != nil {
+			t.Errorf("AddTaskDependency(3, 2) after the removal: %v", err)
+		}
+	})
+}
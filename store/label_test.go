@@ -0,0 +1,108 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"task-management-system/models"
+	"testing"
+)
+
+func TestLabelFilterAndCounts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		bob := newUser(t, s, "bob")
+		var labels []models.Label
+		for _, name := range []string{"home", "work", "later"} {
+			label := models.Label{OwnerID: alice.ID, Name: name, Color: "#336699"}
+			if err := s.Labels.CreateLabel(&label); err != nil {
+				t.Fatal(err)
+			}
+			labels = append(labels, label)
+		}
+		home, work, later := labels[0], labels[1], labels[2]
+		if err := s.Labels.CreateLabel(&models.Label{OwnerID: alice.ID, Name: "home", Color: "#000000"}); !errors.Is(err, ErrConflict) {
+			t.Errorf("duplicate label name: %v, want ErrConflict", err)
+		}
+
+		both := newTask(t, s, "both", alice.ID, alice.ID, "pending")
+		onlyHome := newTask(t, s, "only home", alice.ID, alice.ID, "pending")
+		onlyWork := newTask(t, s, "only work", alice.ID, alice.ID, "pending")
+		parent := newTask(t, s, "parent", alice.ID, alice.ID, "pending")
+		trashed := newTask(t, s, "trashed", alice.ID, alice.ID, "pending")
+		bobs := newTask(t, s, "bob's", bob.ID, bob.ID, "pending")
+		newTask(t, s, "unlabelled", alice.ID, alice.ID, "pending")
+		child := newTask(t, s, "child", alice.ID, alice.ID, "pending")
+		if err := s.Tasks.SetTaskParent(child.ID, &parent.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, tl := range []struct {
+			task  models.Task
+			label models.Label
+		}{
+			{both, home}, {both, work}, {both, work}, {onlyHome, home}, {onlyWork, work},
+			{parent, home}, {trashed, home}, {bobs, home}, {onlyWork, later},
+		} {
+			if err := s.Labels.AddTaskLabel(tl.task.ID, tl.label.ID); err != nil {
+				t.Fatalf("AddTaskLabel(%s, %s): %v", tl.task.Title, tl.label.Name, err)
+			}
+		}
+		if err := s.Tasks.TrashTask(trashed.ID, alice.ID, day, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// a task with several of the labels is listed once, on every page size
+		q := TaskQuery{LabelIDs: []int{home.ID, work.ID}, Sort: TaskSort{Key: "title"}}
+		want := []string{"bob's", "both", "only home", "only work", "parent"}
+		for _, limit := range []int{1, 2, 10} {
+			q.Limit, q.Cursor = limit, ""
+			var got []string
+			for {
+				page, err := s.Tasks.ListTasks(q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if page.Total != len(want) {
+					t.Errorf("limit %d: Total = %d, want %d", limit, page.Total, len(want))
+				}
+				got = append(got, titles(page.Tasks)...)
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("limit %d: pages = %v, want %v", limit, got, want)
+			}
+		}
+		q = TaskQuery{LabelIDs: []int{home.ID}, VisibleTo: alice.ID, LeafOnly: true}
+		if page, err := s.Tasks.ListTasks(q); err != nil || !reflect.DeepEqual(titles(page.Tasks), []string{"both", "only home"}) {
+			t.Errorf("leaves of alice labelled home = %v, %v", titles(page.Tasks), err)
+		}
+
+		tests := []struct {
+			leafOnly bool
+			want     map[int]int
+		}{
+			// the trash and bob's task are not counted
+			{false, map[int]int{home.ID: 3, work.ID: 2, later.ID: 1}},
+			{true, map[int]int{home.ID: 2, work.ID: 2, later.ID: 1}},
+		}
+		for _, tt := range tests {
+			counts, err := s.Tasks.CountTasksByLabel(alice.ID, tt.leafOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("CountTasksByLabel(leafOnly %v) = %v, want %v", tt.leafOnly, counts, tt.want)
+			}
+		}
+
+		// deleting a label takes it off the tasks
+		if err := s.Labels.DeleteLabel(work.ID); err != nil {
+			t.Fatal(err)
+		}
+		if page, _ := s.Tasks.ListTasks(TaskQuery{LabelIDs: []int{work.ID}}); page.Total != 0 {
+			t.Errorf("%d tasks still have the deleted label", page.Total)
+		}
+	})
+}
@@ -0,0 +1,1564 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"task-management-system/models"
+	"time"
+)
+
+// The Memory* types are the in-memory implementations of the stores. They
+// keep everything in process memory and are meant for tests and local
+// experiments. Stores that record events share a MemoryOutboxStore, see
+// their Events fields.
+
+type MemoryTaskStore struct {
+	mu     sync.RWMutex
+	nextID int
+	tasks  map[int]models.Task
+	// Labels, when set, is used for the TaskQuery.LabelIDs filter.
+	Labels *MemoryLabelStore
+	// Events, when set, records the events of changes. Without it events
+	// are dropped.
+	Events *MemoryOutboxStore
+	// History, when set, records the history entries of changes.
+	History *MemoryTaskHistoryStore
+}
+
+func NewMemoryTaskStore() *MemoryTaskStore {
+	return &MemoryTaskStore{tasks: make(map[int]models.Task)}
+}
+
+func (s *MemoryTaskStore) CreateTask(task *models.Task, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	task.ID = s.nextID
+	return s.Events.record(event, s.History, func() { s.tasks[task.ID] = *task })
+}
+
+func (s *MemoryTaskStore) GetTask(id int) (models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return task, ErrNotFound
+	}
+	return task, nil
+}
+
+func (s *MemoryTaskStore) MarkTaskOverdue(id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if ok && task.OverdueAt == nil {
+		task.OverdueAt = &at
+		s.tasks[id] = task
+	}
+	return nil
+}
+
+func (s *MemoryTaskStore) UpdateTask(task models.Task, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.tasks[task.ID]
+	if !ok {
+		return nil
+	}
+	// user_id, the parent and the trash state are never changed by
+	// UpdateTask, same as the SQL store.
+	task.UserID = existing.UserID
+	task.ParentID = existing.ParentID
+	task.DeletedAt, task.DeletedBy = existing.DeletedAt, existing.DeletedBy
+	return s.Events.record(event, s.History, func() { s.tasks[task.ID] = task })
+}
+
+func (s *MemoryTaskStore) TrashTask(id, deletedBy int, at time.Time, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return ErrNotFound
+	}
+	task.DeletedAt, task.DeletedBy = &at, &deletedBy
+	return s.Events.record(event, s.History, func() { s.tasks[id] = task })
+}
+
+func (s *MemoryTaskStore) RestoreTask(id int, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return ErrNotFound
+	}
+	task.DeletedAt, task.DeletedBy = nil, nil
+	return s.Events.record(event, s.History, func() { s.tasks[id] = task })
+}
+
+func (s *MemoryTaskStore) PurgeTasks(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, task := range s.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(before) {
+			delete(s.tasks, id)
+			n++
+		}
+	}
+	for id, task := range s.tasks {
+		if task.ParentID == nil {
+			continue
+		}
+		if _, ok := s.tasks[*task.ParentID]; !ok {
+			task.ParentID = nil
+			s.tasks[id] = task
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryTaskStore) ListSubtasks(parentID int) ([]models.Task, error) {
+	return s.filter(func(t models.Task) bool {
+		return t.ParentID != nil && *t.ParentID == parentID && t.DeletedAt == nil
+	}), nil
+}
+
+func (s *MemoryTaskStore) SetTaskParent(id int, parentID *int, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for ancestor, seen := parentID, map[int]bool{}; ancestor != nil; ancestor = s.tasks[*ancestor].ParentID {
+		if *ancestor == id {
+			return ErrSubtaskCycle
+		}
+		if seen[*ancestor] {
+			return fmt.Errorf("the parents of task %d form a cycle", *parentID)
+		}
+		seen[*ancestor] = true
+	}
+	task.ParentID = parentID
+	return s.Events.record(event, s.History, func() { s.tasks[id] = task })
+}
+
+func (s *MemoryTaskStore) ListTasks(q TaskQuery) (TaskPage, error) {
+	var page TaskPage
+	q.Sort = q.sort()
+	parents := s.parents()
+	tasks := s.filter(func(t models.Task) bool {
+		return matchesTaskQuery(t, q) && (!q.LeafOnly || !parents[t.ID]) && s.hasAnyLabel(t.ID, q.LabelIDs)
+	})
+	page.Total = len(tasks)
+
+	less := func(a, b models.Task) bool {
+		if c := compareTasks(a, b, q.Sort.Key); c != 0 {
+			return (c < 0) != q.Sort.Desc
+		}
+		if q.Sort.Desc {
+			return a.ID > b.ID
+		}
+		return a.ID < b.ID
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return less(tasks[i], tasks[j]) })
+
+	if q.Cursor != "" {
+		c, err := decodeTaskCursor(q.Sort, q.Cursor)
+		if err != nil {
+			return page, err
+		}
+		after, err := cursorTask(c, q.Sort.Key)
+		if err != nil {
+			return page, err
+		}
+		i := 0
+		for i < len(tasks) && !less(after, tasks[i]) {
+			i++
+		}
+		tasks = tasks[i:]
+	}
+
+	if limit := q.limit(); len(tasks) > limit {
+		tasks = tasks[:limit]
+		page.NextCursor = encodeTaskCursor(q.Sort, tasks[limit-1])
+	}
+	page.Tasks = tasks
+	return page, nil
+}
+
+func matchesTaskQuery(t models.Task, q TaskQuery) bool {
+	if (t.DeletedAt != nil) != q.Trashed {
+		return false
+	}
+	if q.VisibleTo != 0 && t.UserID != q.VisibleTo && t.AssignedTo != q.VisibleTo {
+		return false
+	}
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			found = found || t.Status == status
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(q.Priorities) > 0 {
+		found := false
+		for _, priority := range q.Priorities {
+			found = found || t.Priority == priority
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Overdue && t.OverdueAt == nil {
+		return false
+	}
+	if (q.AssignedTo != 0 && t.AssignedTo != q.AssignedTo) || (q.CreatedBy != 0 && t.UserID != q.CreatedBy) {
+		return false
+	}
+	if (!q.DueFrom.IsZero() && t.DueDate.Before(q.DueFrom)) || (!q.DueTo.IsZero() && t.DueDate.After(q.DueTo)) {
+		return false
+	}
+	if (!q.StartFrom.IsZero() && t.StartDate.Before(q.StartFrom)) || (!q.StartTo.IsZero() && t.StartDate.After(q.StartTo)) {
+		return false
+	}
+	return true
+}
+
+func compareTasks(a, b models.Task, key string) int {
+	switch key {
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "status":
+		return strings.Compare(a.Status, b.Status)
+	case "priority":
+		return models.PriorityRank(a.Priority) - models.PriorityRank(b.Priority)
+	case "start_date":
+		return a.StartDate.Compare(b.StartDate)
+	case "due_date":
+		return a.DueDate.Compare(b.DueDate)
+	}
+	return 0
+}
+
+// cursorTask rebuilds enough of the last task of the previous page to
+// compare other tasks against it.
+func cursorTask(c taskCursor, key string) (models.Task, error) {
+	task := models.Task{ID: c.ID}
+	value, err := c.arg(key)
+	if err != nil {
+		return task, err
+	}
+	switch key {
+	case "title":
+		task.Title = c.Value
+	case "status":
+		task.Status = c.Value
+	case "priority":
+		task.Priority = c.Value
+	case "start_date":
+		task.StartDate = value.(time.Time)
+	case "due_date":
+		task.DueDate = value.(time.Time)
+	}
+	return task, nil
+}
+
+// parents returns the IDs of the tasks that have live subtasks.
+func (s *MemoryTaskStore) parents() map[int]bool {
+	parents := map[int]bool{}
+	for _, task := range s.filter(func(t models.Task) bool { return t.ParentID != nil && t.DeletedAt == nil }) {
+		parents[*task.ParentID] = true
+	}
+	return parents
+}
+
+func (s *MemoryTaskStore) hasAnyLabel(taskID int, labelIDs []int) bool {
+	if len(labelIDs) == 0 {
+		return true
+	}
+	if s.Labels == nil {
+		return false
+	}
+	s.Labels.mu.Lock()
+	defer s.Labels.mu.Unlock()
+	for _, id := range labelIDs {
+		if s.Labels.taskLabels[[2]int{taskID, id}] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryTaskStore) CountTasksByStatus(userID int, leafOnly bool) (map[string]int, error) {
+	parents := s.parents()
+	counts := map[string]int{}
+	for _, task := range s.filter(func(t models.Task) bool { return t.AssignedTo == userID && t.DeletedAt == nil }) {
+		if !leafOnly || !parents[task.ID] {
+			counts[task.Status]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *MemoryTaskStore) CountTasksByLabel(userID int, leafOnly bool) (map[int]int, error) {
+	parents := s.parents()
+	counts := map[int]int{}
+	if s.Labels == nil {
+		return counts, nil
+	}
+	tasks := s.filter(func(t models.Task) bool {
+		return t.AssignedTo == userID && t.DeletedAt == nil && (!leafOnly || !parents[t.ID])
+	})
+	s.Labels.mu.Lock()
+	defer s.Labels.mu.Unlock()
+	for _, task := range tasks {
+		for key := range s.Labels.taskLabels {
+			if key[0] == task.ID {
+				counts[key[1]]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (s *MemoryTaskStore) CountTasksByPriority(userID int, statuses []string, leafOnly bool) (map[string]int, error) {
+	counts := map[string]int{}
+	if len(statuses) == 0 {
+		return counts, nil
+	}
+	parents := s.parents()
+	q := TaskQuery{AssignedTo: userID, Statuses: statuses}
+	for _, task := range s.filter(func(t models.Task) bool { return matchesTaskQuery(t, q) }) {
+		if !leafOnly || !parents[task.ID] {
+			counts[task.Priority]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *MemoryTaskStore) filter(keep func(models.Task) bool) []models.Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var tasks []models.Task
+	for _, task := range s.tasks {
+		if keep(task) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+type MemoryUserStore struct {
+	mu     sync.RWMutex
+	nextID int
+	users  map[int]models.User
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[int]models.User)}
+}
+
+func (s *MemoryUserStore) CreateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Username == user.Username {
+			return ErrConflict
+		}
+	}
+	s.nextID++
+	user.ID = s.nextID
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *MemoryUserStore) GetUser(id int) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[id]
+	if !ok {
+		return user, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *MemoryUserStore) SetUserRole(id int, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role
+	s.users[id] = user
+	return nil
+}
+
+func (s *MemoryUserStore) GetUserByUsername(username string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+type MemoryFriendshipStore struct {
+	mu          sync.Mutex
+	nextID      int
+	friendships map[int]models.Friendship
+	// Events, when set, records the events of changes.
+	Events *MemoryOutboxStore
+}
+
+func NewMemoryFriendshipStore() *MemoryFriendshipStore {
+	return &MemoryFriendshipStore{friendships: make(map[int]models.Friendship)}
+}
+
+func (s *MemoryFriendshipStore) CreateFriendship(friendship *models.Friendship, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	friendship.ID = s.nextID
+	return s.Events.record(event, nil, func() { s.friendships[friendship.ID] = *friendship })
+}
+
+func (s *MemoryFriendshipStore) GetFriendship(userID, friendID int) (models.Friendship, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var latest models.Friendship
+	for _, f := range s.friendships {
+		if f.UserID == userID && f.FriendID == friendID && f.ID > latest.ID {
+			latest = f
+		}
+	}
+	if latest.ID == 0 {
+		return latest, ErrNotFound
+	}
+	return latest, nil
+}
+
+func (s *MemoryFriendshipStore) UpdateFriendshipStatus(userID, friendID int, status string, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Events.record(event, nil, func() {
+		for id, f := range s.friendships {
+			if f.UserID == userID && f.FriendID == friendID {
+				f.Status = status
+				s.friendships[id] = f
+			}
+		}
+	})
+}
+
+func (s *MemoryFriendshipStore) AcceptFriendship(friendship *models.Friendship, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for id, f := range s.friendships {
+		if f.UserID == friendship.UserID && f.FriendID == friendship.FriendID && f.Status == "pending" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ErrNotFound
+	}
+	friendship.Status = "accepted"
+	for _, id := range ids {
+		if id > friendship.ID {
+			friendship.ID = id
+		}
+	}
+	return s.Events.record(event, nil, func() {
+		for _, id := range ids {
+			f := s.friendships[id]
+			f.Status = "accepted"
+			s.friendships[id] = f
+		}
+	})
+}
+
+type MemoryRefreshTokenStore struct {
+	mu     sync.Mutex
+	nextID int
+	tokens map[int]models.RefreshToken
+}
+
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{tokens: make(map[int]models.RefreshToken)}
+}
+
+func (s *MemoryRefreshTokenStore) CreateRefreshToken(token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	token.ID = s.nextID
+	s.tokens[token.ID] = *token
+	return nil
+}
+
+func (s *MemoryRefreshTokenStore) GetRefreshTokenByHash(hash string) (models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range s.tokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNotFound
+}
+
+func (s *MemoryRefreshTokenStore) RevokeRefreshToken(id int, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.RevokedAt = &at
+	s.tokens[id] = token
+	return true, nil
+}
+
+func (s *MemoryRefreshTokenStore) RevokeRefreshTokenFamily(familyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			s.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (s *MemoryRefreshTokenStore) RevokeUserRefreshTokens(userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			s.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+type MemoryRevocationStore struct {
+	mu       sync.Mutex
+	tokens   map[string]time.Time // jti -> expires at
+	sessions map[int]time.Time    // user id -> revoked at
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{tokens: make(map[string]time.Time), sessions: make(map[int]time.Time)}
+}
+
+func (s *MemoryRevocationStore) RevokeToken(jti string, userID int, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsTokenRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokens[jti]; ok {
+		return true, nil
+	}
+	revokedAt, ok := s.sessions[userID]
+	return ok && !issuedAt.After(revokedAt), nil
+}
+
+func (s *MemoryRevocationStore) RevokeUserSessions(userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[userID] = at.Truncate(time.Second)
+	return nil
+}
+
+func (s *MemoryRevocationStore) PruneRevokedTokens(now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for jti, expiresAt := range s.tokens {
+		if expiresAt.Before(now) {
+			delete(s.tokens, jti)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MemoryRoleStore does not know about users, so DeleteRole never reports
+// ErrConflict.
+type MemoryRoleStore struct {
+	mu    sync.RWMutex
+	roles map[string][]string
+}
+
+// NewMemoryRoleStore starts with the same admin and user roles the
+// migrations seed.
+func NewMemoryRoleStore() *MemoryRoleStore {
+	return &MemoryRoleStore{roles: map[string][]string{
+		"admin": {
+			models.PermTaskCreate, models.PermTaskRead, models.PermTaskUpdateOwn, models.PermTaskDeleteOwn,
+			models.PermTaskTransition, models.PermTaskComment, models.PermFriendshipManage, models.PermUserAdmin,
+			models.PermWebhookManage,
+		},
+		"user": {models.PermTaskRead, models.PermTaskTransition, models.PermTaskComment, models.PermFriendshipManage},
+	}}
+}
+
+func (s *MemoryRoleStore) ListRoles() ([]models.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var roles []models.Role
+	for name, permissions := range s.roles {
+		roles = append(roles, models.Role{Name: name, Permissions: sortedCopy(permissions)})
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (s *MemoryRoleStore) GetRole(name string) (models.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	permissions, ok := s.roles[name]
+	if !ok {
+		return models.Role{}, ErrNotFound
+	}
+	return models.Role{Name: name, Permissions: sortedCopy(permissions)}, nil
+}
+
+func (s *MemoryRoleStore) CreateRole(role models.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.roles[role.Name]; ok {
+		return ErrConflict
+	}
+	s.roles[role.Name] = sortedCopy(role.Permissions)
+	return nil
+}
+
+func (s *MemoryRoleStore) SetRolePermissions(name string, permissions []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.roles[name]; !ok {
+		return ErrNotFound
+	}
+	s.roles[name] = sortedCopy(permissions)
+	return nil
+}
+
+func (s *MemoryRoleStore) DeleteRole(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.roles[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.roles, name)
+	return nil
+}
+
+func sortedCopy(values []string) []string {
+	out := append([]string{}, values...)
+	sort.Strings(out)
+	return out
+}
+
+type MemoryTaskHistoryStore struct {
+	mu      sync.Mutex
+	entries []models.TaskHistoryEntry
+}
+
+func NewMemoryTaskHistoryStore() *MemoryTaskHistoryStore {
+	return &MemoryTaskHistoryStore{}
+}
+
+func (s *MemoryTaskHistoryStore) add(entries []models.TaskHistoryEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range entries {
+		e.ID = len(s.entries) + 1
+		s.entries = append(s.entries, e)
+	}
+}
+
+func (s *MemoryTaskHistoryStore) ListTaskHistory(taskID int) ([]models.TaskHistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []models.TaskHistoryEntry
+	for _, e := range s.entries {
+		if e.TaskID == taskID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+type MemoryTaskDependencyStore struct {
+	mu           sync.Mutex
+	dependencies map[models.TaskDependency]bool
+}
+
+func NewMemoryTaskDependencyStore() *MemoryTaskDependencyStore {
+	return &MemoryTaskDependencyStore{dependencies: make(map[models.TaskDependency]bool)}
+}
+
+func (s *MemoryTaskDependencyStore) AddTaskDependency(taskID, blockedBy int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := models.TaskDependency{TaskID: taskID, BlockedBy: blockedBy}
+	if s.dependencies[d] {
+		return ErrConflict
+	}
+	seen := map[int]bool{blockedBy: true}
+	for queue := []int{blockedBy}; len(queue) > 0; queue = queue[1:] {
+		for other := range s.dependencies {
+			if other.TaskID != queue[0] {
+				continue
+			}
+			if other.BlockedBy == taskID {
+				return ErrDependencyCycle
+			}
+			if !seen[other.BlockedBy] {
+				seen[other.BlockedBy] = true
+				queue = append(queue, other.BlockedBy)
+			}
+		}
+	}
+	s.dependencies[d] = true
+	return nil
+}
+
+func (s *MemoryTaskDependencyStore) RemoveTaskDependency(taskID, blockedBy int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := models.TaskDependency{TaskID: taskID, BlockedBy: blockedBy}
+	if !s.dependencies[d] {
+		return ErrNotFound
+	}
+	delete(s.dependencies, d)
+	return nil
+}
+
+func (s *MemoryTaskDependencyStore) ListBlockers(taskID int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for d := range s.dependencies {
+		if d.TaskID == taskID {
+			ids = append(ids, d.BlockedBy)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (s *MemoryTaskDependencyStore) ListBlockedTasks(taskID int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for d := range s.dependencies {
+		if d.BlockedBy == taskID {
+			ids = append(ids, d.TaskID)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+type MemoryLabelStore struct {
+	mu         sync.Mutex
+	nextID     int
+	labels     map[int]models.Label
+	taskLabels map[[2]int]bool // task id, label id
+}
+
+func NewMemoryLabelStore() *MemoryLabelStore {
+	return &MemoryLabelStore{labels: make(map[int]models.Label), taskLabels: make(map[[2]int]bool)}
+}
+
+func (s *MemoryLabelStore) ListLabels(ownerID int) ([]models.Label, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var labels []models.Label
+	for _, label := range s.labels {
+		if label.OwnerID == ownerID {
+			labels = append(labels, label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels, nil
+}
+
+func (s *MemoryLabelStore) GetLabel(id int) (models.Label, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	label, ok := s.labels[id]
+	if !ok {
+		return label, ErrNotFound
+	}
+	return label, nil
+}
+
+func (s *MemoryLabelStore) nameTaken(label models.Label) bool {
+	for _, l := range s.labels {
+		if l.OwnerID == label.OwnerID && l.Name == label.Name && l.ID != label.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryLabelStore) CreateLabel(label *models.Label) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nameTaken(*label) {
+		return ErrConflict
+	}
+	s.nextID++
+	label.ID = s.nextID
+	s.labels[label.ID] = *label
+	return nil
+}
+
+func (s *MemoryLabelStore) UpdateLabel(label models.Label) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nameTaken(label) {
+		return ErrConflict
+	}
+	if _, ok := s.labels[label.ID]; !ok {
+		return ErrNotFound
+	}
+	s.labels[label.ID] = label
+	return nil
+}
+
+func (s *MemoryLabelStore) DeleteLabel(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.labels[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.labels, id)
+	for key := range s.taskLabels {
+		if key[1] == id {
+			delete(s.taskLabels, key)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryLabelStore) AddTaskLabel(taskID, labelID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskLabels[[2]int{taskID, labelID}] = true
+	return nil
+}
+
+func (s *MemoryLabelStore) RemoveTaskLabel(taskID, labelID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.taskLabels[[2]int{taskID, labelID}] {
+		return ErrNotFound
+	}
+	delete(s.taskLabels, [2]int{taskID, labelID})
+	return nil
+}
+
+func (s *MemoryLabelStore) ListTaskLabels(taskID, ownerID int) ([]models.Label, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var labels []models.Label
+	for key := range s.taskLabels {
+		if label, ok := s.labels[key[1]]; ok && key[0] == taskID && label.OwnerID == ownerID {
+			labels = append(labels, label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels, nil
+}
+
+type MemoryCommentStore struct {
+	mu       sync.Mutex
+	nextID   int
+	comments map[int]models.Comment
+	edits    []models.CommentEdit
+	// Events, when set, records the events of changes.
+	Events *MemoryOutboxStore
+}
+
+func NewMemoryCommentStore() *MemoryCommentStore {
+	return &MemoryCommentStore{comments: make(map[int]models.Comment)}
+}
+
+func (s *MemoryCommentStore) CreateComment(comment *models.Comment, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	comment.ID = s.nextID
+	return s.Events.record(event, nil, func() { s.comments[comment.ID] = *comment })
+}
+
+func (s *MemoryCommentStore) GetComment(id int) (models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.comments[id]
+	if !ok {
+		return c, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *MemoryCommentStore) ListComments(taskID int) ([]models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var comments []models.Comment
+	for _, c := range s.comments {
+		if c.TaskID == taskID {
+			comments = append(comments, c)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+	return comments, nil
+}
+
+func (s *MemoryCommentStore) UpdateComment(id int, body string, at time.Time, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.comments[id]
+	if !ok {
+		return ErrNotFound
+	}
+	return s.Events.record(event, nil, func() {
+		s.edits = append(s.edits, models.CommentEdit{ID: len(s.edits) + 1, CommentID: id, Body: c.Body, EditedAt: at})
+		c.Body, c.UpdatedAt = body, &at
+		s.comments[id] = c
+	})
+}
+
+func (s *MemoryCommentStore) DeleteComment(id int, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.comments[id]; !ok {
+		return ErrNotFound
+	}
+	return s.Events.record(event, nil, func() {
+		delete(s.comments, id)
+		edits := s.edits[:0]
+		for _, e := range s.edits {
+			if e.CommentID != id {
+				edits = append(edits, e)
+			}
+		}
+		s.edits = edits
+	})
+}
+
+func (s *MemoryCommentStore) ListCommentEdits(commentID int) ([]models.CommentEdit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var edits []models.CommentEdit
+	for _, e := range s.edits {
+		if e.CommentID == commentID {
+			edits = append(edits, e)
+		}
+	}
+	return edits, nil
+}
+
+type MemoryNotificationStore struct {
+	mu            sync.Mutex
+	notifications []models.Notification
+	preferences   map[int]map[string][]string
+}
+
+func NewMemoryNotificationStore() *MemoryNotificationStore {
+	return &MemoryNotificationStore{preferences: make(map[int]map[string][]string)}
+}
+
+func (s *MemoryNotificationStore) CreateNotification(n *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.notifications {
+		if n.EventID != 0 && existing.UserID == n.UserID && existing.EventID == n.EventID {
+			return ErrConflict
+		}
+	}
+	n.ID = len(s.notifications) + 1
+	s.notifications = append(s.notifications, *n)
+	return nil
+}
+
+func (s *MemoryNotificationStore) ListNotifications(userID int, unreadOnly bool) ([]models.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var notifications []models.Notification
+	for i := len(s.notifications) - 1; i >= 0; i-- {
+		n := s.notifications[i]
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
+
+func (s *MemoryNotificationStore) CountUnreadNotifications(userID int) (int, error) {
+	unread, err := s.ListNotifications(userID, true)
+	return len(unread), err
+}
+
+func (s *MemoryNotificationStore) SetNotificationRead(id, userID int, readAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > len(s.notifications) || s.notifications[id-1].UserID != userID {
+		return ErrNotFound
+	}
+	s.notifications[id-1].ReadAt = readAt
+	return nil
+}
+
+func (s *MemoryNotificationStore) MarkAllNotificationsRead(userID int, at time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for i := range s.notifications {
+		if s.notifications[i].UserID == userID && s.notifications[i].ReadAt == nil {
+			readAt := at
+			s.notifications[i].ReadAt = &readAt
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryNotificationStore) GetNotificationPreferences(userID int) (map[string][]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs := map[string][]string{}
+	for t, c := range s.preferences[userID] {
+		prefs[t] = append([]string{}, c...)
+	}
+	return prefs, nil
+}
+
+func (s *MemoryNotificationStore) SetNotificationPreferences(userID int, channels map[string][]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs := map[string][]string{}
+	for t, c := range channels {
+		prefs[t] = append([]string{}, c...)
+	}
+	s.preferences[userID] = prefs
+	return nil
+}
+
+// MemoryAttachmentStore does not know about tasks, ListOrphanedAttachments
+// uses Tasks when it is set.
+type MemoryAttachmentStore struct {
+	mu          sync.Mutex
+	nextID      int
+	attachments map[int]models.Attachment
+	Tasks       *MemoryTaskStore
+}
+
+func NewMemoryAttachmentStore() *MemoryAttachmentStore {
+	return &MemoryAttachmentStore{attachments: make(map[int]models.Attachment)}
+}
+
+func (s *MemoryAttachmentStore) CreateAttachment(a *models.Attachment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	a.ID = s.nextID
+	s.attachments[a.ID] = *a
+	return nil
+}
+
+func (s *MemoryAttachmentStore) GetAttachment(id int) (models.Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attachments[id]
+	if !ok {
+		return a, ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *MemoryAttachmentStore) ListAttachments(taskID int) ([]models.Attachment, error) {
+	return s.filter(func(a models.Attachment) bool { return a.TaskID == taskID }), nil
+}
+
+func (s *MemoryAttachmentStore) DeleteAttachment(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.attachments[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.attachments, id)
+	return nil
+}
+
+func (s *MemoryAttachmentStore) ListOrphanedAttachments() ([]models.Attachment, error) {
+	if s.Tasks == nil {
+		return nil, nil
+	}
+	return s.filter(func(a models.Attachment) bool {
+		_, err := s.Tasks.GetTask(a.TaskID)
+		return errors.Is(err, ErrNotFound)
+	}), nil
+}
+
+func (s *MemoryAttachmentStore) filter(keep func(models.Attachment) bool) []models.Attachment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var attachments []models.Attachment
+	for _, a := range s.attachments {
+		if keep(a) {
+			attachments = append(attachments, a)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+	return attachments
+}
+
+type MemoryRecurrenceStore struct {
+	mu          sync.Mutex
+	recurrences map[int]models.TaskRecurrence
+	// Tasks holds the occurrences created by CreateOccurrence.
+	Tasks *MemoryTaskStore
+}
+
+func NewMemoryRecurrenceStore() *MemoryRecurrenceStore {
+	return &MemoryRecurrenceStore{recurrences: make(map[int]models.TaskRecurrence)}
+}
+
+func (s *MemoryRecurrenceStore) SetRecurrence(rec models.TaskRecurrence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.recurrences[rec.TaskID]; ok {
+		rec.CreatedAt = old.CreatedAt
+	}
+	s.recurrences[rec.TaskID] = rec
+	return nil
+}
+
+func (s *MemoryRecurrenceStore) GetRecurrence(taskID int) (models.TaskRecurrence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recurrences[taskID]
+	if !ok {
+		return rec, ErrNotFound
+	}
+	return rec, nil
+}
+
+func (s *MemoryRecurrenceStore) DeleteRecurrence(taskID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.recurrences[taskID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.recurrences, taskID)
+	return nil
+}
+
+func (s *MemoryRecurrenceStore) ListActiveRecurrences() ([]models.TaskRecurrence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var recs []models.TaskRecurrence
+	for _, rec := range s.recurrences {
+		if rec.NextStart != nil {
+			recs = append(recs, rec)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].TaskID < recs[j].TaskID })
+	return recs, nil
+}
+
+func (s *MemoryRecurrenceStore) CreateOccurrence(taskID int, occurrence *models.Task, nextStart *time.Time, event EventFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recurrences[taskID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := s.Tasks.CreateTask(occurrence, event); err != nil {
+		return err
+	}
+	rec.LastTaskID, rec.NextStart = occurrence.ID, nextStart
+	s.recurrences[taskID] = rec
+	return nil
+}
+
+type MemoryReminderStore struct {
+	mu          sync.Mutex
+	preferences map[int]models.ReminderPreferences
+	deliveries  map[models.ReminderDelivery]bool
+}
+
+func NewMemoryReminderStore() *MemoryReminderStore {
+	return &MemoryReminderStore{
+		preferences: make(map[int]models.ReminderPreferences),
+		deliveries:  make(map[models.ReminderDelivery]bool),
+	}
+}
+
+func (s *MemoryReminderStore) GetReminderPreferences(userID int) (models.ReminderPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		return models.ReminderPreferences{UserID: userID}, ErrNotFound
+	}
+	prefs.LeadHours = append([]int(nil), prefs.LeadHours...)
+	return prefs, nil
+}
+
+func (s *MemoryReminderStore) SetReminderPreferences(prefs models.ReminderPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs.LeadHours = append([]int(nil), prefs.LeadHours...)
+	s.preferences[prefs.UserID] = prefs
+	return nil
+}
+
+func (s *MemoryReminderStore) ClaimReminder(d models.ReminderDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := models.ReminderDelivery{TaskID: d.TaskID, UserID: d.UserID, Kind: d.Kind, DueDate: d.DueDate.UTC()}
+	if s.deliveries[key] {
+		return ErrConflict
+	}
+	s.deliveries[key] = true
+	return nil
+}
+
+func (s *MemoryReminderStore) ReleaseReminder(d models.ReminderDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deliveries, models.ReminderDelivery{TaskID: d.TaskID, UserID: d.UserID, Kind: d.Kind, DueDate: d.DueDate.UTC()})
+	return nil
+}
+
+type MemoryOutboxStore struct {
+	mu      sync.Mutex
+	nextID  int64
+	lastSeq int64
+	events  []models.Event
+	// published holds the publish time of the events by ID.
+	published map[int64]time.Time
+	// retries holds the next attempt of the events that failed, a zero time
+	// for the ones given up on.
+	retries map[int64]time.Time
+}
+
+func NewMemoryOutboxStore() *MemoryOutboxStore {
+	return &MemoryOutboxStore{published: make(map[int64]time.Time), retries: make(map[int64]time.Time)}
+}
+
+// record builds the event, then applies the change and stores the event and
+// its history entries, so either all happen or none. A nil store drops the
+// event, a nil history store the entries.
+func (s *MemoryOutboxStore) record(event EventFunc, history *MemoryTaskHistoryStore, apply func()) error {
+	var ev models.Event
+	var entries []models.TaskHistoryEntry
+	if event != nil {
+		var err error
+		if ev, entries, err = event(); err != nil {
+			return err
+		}
+	}
+	apply()
+	if history != nil {
+		history.add(entries)
+	}
+	if event == nil || s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	ev.ID = s.nextID
+	s.events = append(s.events, ev)
+	return nil
+}
+
+func (s *MemoryOutboxStore) ListUnpublishedEvents(now time.Time, limit int) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []models.Event
+	for _, ev := range s.events {
+		if len(events) == limit {
+			break
+		}
+		if _, ok := s.published[ev.ID]; ok {
+			continue
+		}
+		if next, ok := s.retries[ev.ID]; ok && (next.IsZero() || next.After(now)) {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func (s *MemoryOutboxStore) MarkEventFailed(id int64, lastError string, next *time.Time, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.events {
+		if s.events[i].ID == id {
+			s.events[i].Attempts++
+		}
+	}
+	s.retries[id] = time.Time{}
+	if next != nil {
+		s.retries[id] = *next
+	}
+	return nil
+}
+
+func (s *MemoryOutboxStore) SequenceEvents(events []models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range events {
+		if events[i].Seq != 0 {
+			continue
+		}
+		s.lastSeq++
+		events[i].Seq = s.lastSeq
+		for j := range s.events {
+			if s.events[j].ID == events[i].ID {
+				s.events[j].Seq = s.lastSeq
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MemoryOutboxStore) MarkEventsPublished(ids []int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.published[id] = at
+	}
+	return nil
+}
+
+func (s *MemoryOutboxStore) ListPublishedEvents(afterSeq int64, limit int) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []models.Event
+	for _, ev := range s.events {
+		if ev.Seq > afterSeq {
+			events = append(events, ev)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (s *MemoryOutboxStore) PurgeEvents(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	kept := s.events[:0]
+	for _, ev := range s.events {
+		if at, ok := s.published[ev.ID]; ok && at.Before(before) {
+			delete(s.published, ev.ID)
+			delete(s.retries, ev.ID)
+			n++
+			continue
+		}
+		kept = append(kept, ev)
+	}
+	s.events = kept
+	return n, nil
+}
+
+type MemoryWebhookStore struct {
+	mu             sync.Mutex
+	nextID         int
+	nextDeliveryID int
+	webhooks       map[int]models.Webhook
+	deliveries     map[int]models.WebhookDelivery
+}
+
+func NewMemoryWebhookStore() *MemoryWebhookStore {
+	return &MemoryWebhookStore{webhooks: make(map[int]models.Webhook), deliveries: make(map[int]models.WebhookDelivery)}
+}
+
+func (s *MemoryWebhookStore) CreateWebhook(w *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	w.ID = s.nextID
+	w.Events = append([]string{}, w.Events...)
+	s.webhooks[w.ID] = *w
+	return nil
+}
+
+func (s *MemoryWebhookStore) GetWebhook(id int) (models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.webhooks[id]
+	if !ok {
+		return w, ErrNotFound
+	}
+	return w, nil
+}
+
+func (s *MemoryWebhookStore) ListWebhooks(userID int) ([]models.Webhook, error) {
+	return s.filterWebhooks(func(w models.Webhook) bool { return w.UserID == userID }), nil
+}
+
+func (s *MemoryWebhookStore) ListWebhooksForEvent(eventType string) ([]models.Webhook, error) {
+	return s.filterWebhooks(func(w models.Webhook) bool {
+		for _, e := range w.Events {
+			if e == eventType {
+				return w.Active
+			}
+		}
+		return false
+	}), nil
+}
+
+func (s *MemoryWebhookStore) filterWebhooks(keep func(models.Webhook) bool) []models.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var webhooks []models.Webhook
+	for _, w := range s.webhooks {
+		if keep(w) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+func (s *MemoryWebhookStore) UpdateWebhook(w models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[w.ID]; !ok {
+		return ErrNotFound
+	}
+	w.Events = append([]string{}, w.Events...)
+	s.webhooks[w.ID] = w
+	return nil
+}
+
+func (s *MemoryWebhookStore) DeleteWebhook(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.webhooks, id)
+	for deliveryID, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryWebhookStore) CreateDelivery(d *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.deliveries {
+		if existing.WebhookID == d.WebhookID && existing.EventID == d.EventID {
+			return ErrConflict
+		}
+	}
+	s.nextDeliveryID++
+	d.ID = s.nextDeliveryID
+	s.deliveries[d.ID] = *d
+	return nil
+}
+
+func (s *MemoryWebhookStore) GetDelivery(id int) (models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok {
+		return d, ErrNotFound
+	}
+	return d, nil
+}
+
+func (s *MemoryWebhookStore) ListDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (s *MemoryWebhookStore) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status == models.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		a, b := deliveries[i], deliveries[j]
+		if !a.NextAttemptAt.Equal(*b.NextAttemptAt) {
+			return a.NextAttemptAt.Before(*b.NextAttemptAt)
+		}
+		return a.ID < b.ID
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (s *MemoryWebhookStore) UpdateDelivery(d models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return ErrNotFound
+	}
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *MemoryWebhookStore) PurgeDeliveries(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, d := range s.deliveries {
+		if d.Status != models.DeliveryPending && d.CreatedAt.Before(before) {
+			delete(s.deliveries, id)
+			n++
+		}
+	}
+	return n, nil
+}
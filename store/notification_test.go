@@ -0,0 +1,42 @@
+package store
+
+import (
+	"errors"
+	"task-management-system/models"
+	"testing"
+)
+
+func TestCreateNotificationOncePerEvent(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		bob := newUser(t, s, "bob")
+		tests := []struct {
+			name string
+			n    models.Notification
+			want error
+		}{
+			{"event", models.Notification{UserID: alice.ID, EventID: 1}, nil},
+			{"event again", models.Notification{UserID: alice.ID, EventID: 1}, ErrConflict},
+			{"event for another user", models.Notification{UserID: bob.ID, EventID: 1}, nil},
+			{"another event", models.Notification{UserID: alice.ID, EventID: 2}, nil},
+			// notifications without an event are never duplicates
+			{"no event", models.Notification{UserID: alice.ID}, nil},
+			{"no event again", models.Notification{UserID: alice.ID}, nil},
+		}
+		for _, tt := range tests {
+			n := tt.n
+			n.Type, n.Message, n.CreatedAt = models.NotificationMention, tt.name, day
+			if err := s.Notifications.CreateNotification(&n); !errors.Is(err, tt.want) {
+				t.Errorf("%s: CreateNotification = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+
+		notifications, err := s.Notifications.ListNotifications(alice.ID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(notifications) != 4 {
+			t.Errorf("alice has %d notifications, want 4", len(notifications))
+		}
+	})
+}
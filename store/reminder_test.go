@@ -0,0 +1,50 @@
+package store
+
+import (
+	"errors"
+	"task-management-system/models"
+	"testing"
+	"time"
+)
+
+func TestClaimReminder(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		due := models.ReminderDelivery{TaskID: 1, UserID: 2, Kind: "due_soon", DueDate: day, CreatedAt: day}
+		if err := s.Reminders.ClaimReminder(due); err != nil {
+			t.Fatal(err)
+		}
+
+		later := due
+		later.CreatedAt = day.Add(time.Hour)
+		inZone := due
+		inZone.DueDate = day.In(time.FixedZone("UTC+3", 3*60*60))
+		moved := due
+		moved.DueDate = day.AddDate(0, 0, 1)
+		overdue := due
+		overdue.Kind = "overdue"
+		tests := []struct {
+			name string
+			d    models.ReminderDelivery
+			want error
+		}{
+			{"again", due, ErrConflict},
+			{"again later", later, ErrConflict},
+			{"same due date in another zone", inZone, ErrConflict},
+			{"due date moved", moved, nil},
+			{"other kind", overdue, nil},
+		}
+		for _, tt := range tests {
+			if err := s.Reminders.ClaimReminder(tt.d); !errors.Is(err, tt.want) {
+				t.Errorf("%s: ClaimReminder = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+
+		// a released claim, e.g. after a failed send, can be taken again
+		if err := s.Reminders.ReleaseReminder(due); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Reminders.ClaimReminder(later); err != nil {
+			t.Errorf("ClaimReminder after the release: %v", err)
+		}
+	})
+}
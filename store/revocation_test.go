@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevocationStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		revokedAt := day.Add(500 * time.Millisecond)
+		if err := s.Revocations.RevokeToken("logged-out", 1, day.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Revocations.RevokeToken("expired", 1, day.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Revocations.RevokeUserSessions(2, revokedAt); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name     string
+			jti      string
+			userID   int
+			issuedAt time.Time
+			want     bool
+		}{
+			{"revoked token", "logged-out", 1, day, true},
+			{"other token of the user", "other", 1, day, false},
+			{"issued before the sessions were revoked", "a", 2, day.Add(-time.Minute), true},
+			// iat has second precision, so a token of the same second may
+			// predate the revocation
+			{"issued in the second of the revocation", "b", 2, day, true},
+			{"issued after the sessions were revoked", "c", 2, day.Add(time.Second), false},
+			{"other user", "d", 3, day.Add(-time.Minute), false},
+		}
+		for _, tt := range tests {
+			got, err := s.Revocations.IsTokenRevoked(tt.jti, tt.userID, tt.issuedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s: IsTokenRevoked = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		// revoking again moves the cut-off forward
+		if err := s.Revocations.RevokeUserSessions(2, day.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.Revocations.IsTokenRevoked("c", 2, day.Add(time.Second)); !got {
+			t.Error("a token issued before the second revocation is still valid")
+		}
+
+		if n, err := s.Revocations.PruneRevokedTokens(day); err != nil || n != 1 {
+			t.Errorf("PruneRevokedTokens = %d, %v, want only the expired token", n, err)
+		}
+		if got, _ := s.Revocations.IsTokenRevoked("logged-out", 1, day); !got {
+			t.Error("pruning dropped a token that has not expired")
+		}
+	})
+}
@@ -0,0 +1,1723 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-management-system/models"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// The SQL* types are the database/sql implementations of the stores. Queries
+// stick to SQL that both MySQL and SQLite understand. Times are stored in UTC
+// so that SQLite, which keeps them as text, compares them correctly.
+
+const taskColumns = "id, title, description, status, priority, start_date, due_date, user_id, assigned_to, parent_id, deleted_at, deleted_by, overdue_at"
+
+// leafTaskCond matches tasks without live subtasks.
+const leafTaskCond = "NOT EXISTS (SELECT 1 FROM tasks c WHERE c.parent_id = tasks.id AND c.deleted_at IS NULL)"
+
+// maxInParams bounds the number of values bound to a single IN list.
+const maxInParams = 500
+
+type SQLTaskStore struct {
+	DB *sql.DB
+}
+
+func scanTask(row interface{ Scan(...interface{}) error }) (models.Task, error) {
+	var task models.Task
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.StartDate, &task.DueDate, &task.UserID, &task.AssignedTo, &task.ParentID, &task.DeletedAt, &task.DeletedBy, &task.OverdueAt)
+	return task, err
+}
+
+func (s *SQLTaskStore) CreateTask(task *models.Task, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		return insertTask(tx, task)
+	})
+}
+
+func insertTask(tx *sql.Tx, task *models.Task) error {
+	res, err := tx.Exec("INSERT INTO tasks (title, description, status, priority, start_date, due_date, user_id, assigned_to, parent_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		task.Title, task.Description, task.Status, task.Priority, task.StartDate.UTC(), task.DueDate.UTC(), task.UserID, task.AssignedTo, task.ParentID)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	task.ID = int(id)
+	return nil
+}
+
+func (s *SQLTaskStore) GetTask(id int) (models.Task, error) {
+	task, err := scanTask(s.DB.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, ErrNotFound
+	}
+	return task, err
+}
+
+func (s *SQLTaskStore) UpdateTask(task models.Task, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE tasks SET title = ?, description = ?, status = ?, priority = ?, start_date = ?, due_date = ?, assigned_to = ?, overdue_at = ? WHERE id = ?",
+			task.Title, task.Description, task.Status, task.Priority, task.StartDate.UTC(), task.DueDate.UTC(), task.AssignedTo, nullableUTC(task.OverdueAt), task.ID)
+		return err
+	})
+}
+
+func (s *SQLTaskStore) MarkTaskOverdue(id int, at time.Time) error {
+	_, err := s.DB.Exec("UPDATE tasks SET overdue_at = ? WHERE id = ? AND overdue_at IS NULL", at.UTC(), id)
+	return err
+}
+
+func (s *SQLTaskStore) TrashTask(id, deletedBy int, at time.Time, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		return expectOneRow(tx.Exec("UPDATE tasks SET deleted_at = ?, deleted_by = ? WHERE id = ? AND deleted_at IS NULL", at.UTC(), deletedBy, id))
+	})
+}
+
+func (s *SQLTaskStore) RestoreTask(id int, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		return expectOneRow(tx.Exec("UPDATE tasks SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL", id))
+	})
+}
+
+func (s *SQLTaskStore) PurgeTasks(before time.Time) (int64, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// silinen görevlerin alt görevleri üst seviyeye taşınır
+	purged := "SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?"
+	if err := detachSubtasks(tx, purged, before.UTC()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM task_dependencies WHERE task_id IN ("+purged+") OR blocked_by IN ("+purged+")", before.UTC(), before.UTC()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM task_labels WHERE task_id IN ("+purged+")", before.UTC()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM comment_edits WHERE comment_id IN (SELECT id FROM comments WHERE task_id IN ("+purged+"))", before.UTC()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM comments WHERE task_id IN ("+purged+")", before.UTC()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM task_recurrences WHERE task_id IN ("+purged+")", before.UTC()); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// detachSubtasks clears the parent of the children of the tasks the query
+// selects. MySQL cannot update tasks with a subquery on tasks (error 1093),
+// so the IDs are read first.
+func detachSubtasks(tx *sql.Tx, query string, args ...interface{}) error {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	var ids []interface{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxInParams {
+			n = maxInParams
+		}
+		if _, err := tx.Exec("UPDATE tasks SET parent_id = NULL WHERE parent_id IN (?"+strings.Repeat(", ?", n-1)+")", ids[:n]...); err != nil {
+			return err
+		}
+		ids = ids[n:]
+	}
+	return nil
+}
+
+func (s *SQLTaskStore) ListSubtasks(parentID int) ([]models.Task, error) {
+	return s.listTasks("SELECT "+taskColumns+" FROM tasks WHERE parent_id = ? AND deleted_at IS NULL ORDER BY id", parentID)
+}
+
+func (s *SQLTaskStore) SetTaskParent(id int, parentID *int, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		// MySQL reports 0 affected rows when the value does not change
+		var exists int
+		err := tx.QueryRow("SELECT 1 FROM tasks WHERE id = ?", id).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// the handlers check the ancestry too, this catches a concurrent
+		// change that closed the cycle in between
+		for ancestor, seen := parentID, map[int]bool{}; ancestor != nil; {
+			if *ancestor == id {
+				return ErrSubtaskCycle
+			}
+			if seen[*ancestor] {
+				return fmt.Errorf("the parents of task %d form a cycle", *parentID)
+			}
+			seen[*ancestor] = true
+			var next *int
+			err := tx.QueryRow("SELECT parent_id FROM tasks WHERE id = ?", *ancestor).Scan(&next)
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			ancestor = next
+		}
+		_, err = tx.Exec("UPDATE tasks SET parent_id = ? WHERE id = ?", parentID, id)
+		return err
+	})
+}
+
+// withEvent runs change and records the event and the history entries built
+// after it in one transaction.
+func withEvent(db *sql.DB, event EventFunc, change func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	if event != nil {
+		ev, entries, err := event()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO outbox_events (type, actor_id, data, created_at) VALUES (?, ?, ?, ?)",
+			ev.Type, ev.ActorID, string(ev.Data), ev.CreatedAt.UTC()); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if _, err := tx.Exec("INSERT INTO task_history (task_id, actor_id, action, field, old_value, new_value, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				e.TaskID, e.ActorID, e.Action, e.Field, e.OldValue, e.NewValue, e.CreatedAt.UTC()); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether err is a duplicate key error of MySQL or
+// a unique constraint error of SQLite.
+func isUniqueViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// expectOneRow turns an update that matched no row into ErrNotFound.
+func expectOneRow(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLTaskStore) ListTasks(q TaskQuery) (TaskPage, error) {
+	var page TaskPage
+	q.Sort = q.sort()
+	where, args := taskWhere(q)
+
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE "+where, args...).Scan(&page.Total); err != nil {
+		return page, err
+	}
+
+	column, order, cmp := sortColumn(q.Sort.Key), "ASC", ">"
+	if q.Sort.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if q.Cursor != "" {
+		c, err := decodeTaskCursor(q.Sort, q.Cursor)
+		if err != nil {
+			return page, err
+		}
+		if column == "id" {
+			where += " AND id " + cmp + " ?"
+			args = append(args, c.ID)
+		} else {
+			value, err := c.arg(q.Sort.Key)
+			if err != nil {
+				return page, err
+			}
+			where += fmt.Sprintf(" AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, cmp)
+			args = append(args, value, value, c.ID)
+		}
+	}
+
+	orderBy := "id " + order
+	if column != "id" {
+		orderBy = column + " " + order + ", " + orderBy
+	}
+
+	// bir fazlasını çekip sonraki sayfa var mı bakıyoruz
+	limit := q.limit()
+	tasks, err := s.listTasks(fmt.Sprintf("SELECT %s FROM tasks WHERE %s ORDER BY %s LIMIT %d", taskColumns, where, orderBy, limit+1), args...)
+	if err != nil {
+		return page, err
+	}
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		page.NextCursor = encodeTaskCursor(q.Sort, tasks[limit-1])
+	}
+	page.Tasks = tasks
+	return page, nil
+}
+
+// sortColumn returns the SQL expression to order by. Priorities are ordered
+// by urgency, not by name.
+func sortColumn(key string) string {
+	if key != "priority" {
+		return key
+	}
+	expr := "(CASE priority"
+	for _, p := range models.Priorities {
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", p, models.PriorityRank(p))
+	}
+	return expr + " ELSE 0 END)"
+}
+
+func taskWhere(q TaskQuery) (string, []interface{}) {
+	conds := []string{"deleted_at IS NULL"}
+	if q.Trashed {
+		conds[0] = "deleted_at IS NOT NULL"
+	}
+	var args []interface{}
+	if q.VisibleTo != 0 {
+		conds = append(conds, "(user_id = ? OR assigned_to = ?)")
+		args = append(args, q.VisibleTo, q.VisibleTo)
+	}
+	if len(q.Statuses) > 0 {
+		conds = append(conds, "status IN (?"+strings.Repeat(", ?", len(q.Statuses)-1)+")")
+		for _, status := range q.Statuses {
+			args = append(args, status)
+		}
+	}
+	if len(q.Priorities) > 0 {
+		conds = append(conds, "priority IN (?"+strings.Repeat(", ?", len(q.Priorities)-1)+")")
+		for _, priority := range q.Priorities {
+			args = append(args, priority)
+		}
+	}
+	if len(q.LabelIDs) > 0 {
+		conds = append(conds, "id IN (SELECT task_id FROM task_labels WHERE label_id IN (?"+strings.Repeat(", ?", len(q.LabelIDs)-1)+"))")
+		for _, id := range q.LabelIDs {
+			args = append(args, id)
+		}
+	}
+	if q.LeafOnly {
+		conds = append(conds, leafTaskCond)
+	}
+	if q.Overdue {
+		conds = append(conds, "overdue_at IS NOT NULL")
+	}
+	if q.AssignedTo != 0 {
+		conds = append(conds, "assigned_to = ?")
+		args = append(args, q.AssignedTo)
+	}
+	if q.CreatedBy != 0 {
+		conds = append(conds, "user_id = ?")
+		args = append(args, q.CreatedBy)
+	}
+	for _, r := range []struct {
+		cond  string
+		value time.Time
+	}{
+		{"due_date >= ?", q.DueFrom},
+		{"due_date <= ?", q.DueTo},
+		{"start_date >= ?", q.StartFrom},
+		{"start_date <= ?", q.StartTo},
+	} {
+		if !r.value.IsZero() {
+			conds = append(conds, r.cond)
+			args = append(args, r.value.UTC())
+		}
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func (s *SQLTaskStore) listTasks(query string, args ...interface{}) ([]models.Task, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *SQLTaskStore) CountTasksByStatus(userID int, leafOnly bool) (map[string]int, error) {
+	where := "assigned_to = ? AND deleted_at IS NULL"
+	if leafOnly {
+		where += " AND " + leafTaskCond
+	}
+	rows, err := s.DB.Query("SELECT status, COUNT(*) FROM tasks WHERE "+where+" GROUP BY status", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLTaskStore) CountTasksByLabel(userID int, leafOnly bool) (map[int]int, error) {
+	where := "tasks.assigned_to = ? AND tasks.deleted_at IS NULL"
+	if leafOnly {
+		where += " AND " + leafTaskCond
+	}
+	rows, err := s.DB.Query("SELECT task_labels.label_id, COUNT(*) FROM task_labels JOIN tasks ON tasks.id = task_labels.task_id WHERE "+where+" GROUP BY task_labels.label_id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int]int{}
+	for rows.Next() {
+		var labelID, count int
+		if err := rows.Scan(&labelID, &count); err != nil {
+			return nil, err
+		}
+		counts[labelID] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *SQLTaskStore) CountTasksByPriority(userID int, statuses []string, leafOnly bool) (map[string]int, error) {
+	counts := map[string]int{}
+	if len(statuses) == 0 {
+		return counts, nil
+	}
+	where := "assigned_to = ? AND deleted_at IS NULL AND status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"
+	args := []interface{}{userID}
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	if leafOnly {
+		where += " AND " + leafTaskCond
+	}
+	rows, err := s.DB.Query("SELECT priority, COUNT(*) FROM tasks WHERE "+where+" GROUP BY priority", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var priority string
+		var count int
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, err
+		}
+		counts[priority] = count
+	}
+	return counts, rows.Err()
+}
+
+type SQLUserStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLUserStore) CreateUser(user *models.User) error {
+	var n int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", user.Username).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrConflict
+	}
+	res, err := s.DB.Exec("INSERT INTO users (username, password, role, email) VALUES (?, ?, ?, ?)", user.Username, user.Password, user.Role, user.Email)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = int(id)
+	return nil
+}
+
+func (s *SQLUserStore) GetUser(id int) (models.User, error) {
+	return s.getUser("SELECT id, username, password, role, email FROM users WHERE id = ?", id)
+}
+
+func (s *SQLUserStore) GetUserByUsername(username string) (models.User, error) {
+	return s.getUser("SELECT id, username, password, role, email FROM users WHERE username = ?", username)
+}
+
+func (s *SQLUserStore) SetUserRole(id int, role string) error {
+	// MySQL reports 0 affected rows when the role does not change
+	if _, err := s.GetUser(id); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec("UPDATE users SET role = ? WHERE id = ?", role, id)
+	return err
+}
+
+func (s *SQLUserStore) getUser(query string, arg interface{}) (models.User, error) {
+	var user models.User
+	err := s.DB.QueryRow(query, arg).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrNotFound
+	}
+	return user, err
+}
+
+type SQLFriendshipStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLFriendshipStore) CreateFriendship(friendship *models.Friendship, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		res, err := tx.Exec("INSERT INTO friendships (user_id, friend_id, status) VALUES (?, ?, ?)", friendship.UserID, friendship.FriendID, friendship.Status)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		friendship.ID = int(id)
+		return nil
+	})
+}
+
+func (s *SQLFriendshipStore) GetFriendship(userID, friendID int) (models.Friendship, error) {
+	var f models.Friendship
+	err := s.DB.QueryRow("SELECT id, user_id, friend_id, status FROM friendships WHERE user_id = ? AND friend_id = ? ORDER BY id DESC LIMIT 1", userID, friendID).
+		Scan(&f.ID, &f.UserID, &f.FriendID, &f.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *SQLFriendshipStore) UpdateFriendshipStatus(userID, friendID int, status string, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE friendships SET status = ? WHERE user_id = ? AND friend_id = ?", status, userID, friendID)
+		return err
+	})
+}
+
+// AcceptFriendship checks and changes the status in one statement, so of two
+// concurrent accepts only one records the event.
+func (s *SQLFriendshipStore) AcceptFriendship(friendship *models.Friendship, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		res, err := tx.Exec("UPDATE friendships SET status = 'accepted' WHERE user_id = ? AND friend_id = ? AND status = 'pending'",
+			friendship.UserID, friendship.FriendID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		friendship.Status = "accepted"
+		return tx.QueryRow("SELECT id FROM friendships WHERE user_id = ? AND friend_id = ? AND status = 'accepted' ORDER BY id DESC LIMIT 1",
+			friendship.UserID, friendship.FriendID).Scan(&friendship.ID)
+	})
+}
+
+type SQLRefreshTokenStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLRefreshTokenStore) CreateRefreshToken(token *models.RefreshToken) error {
+	res, err := s.DB.Exec("INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt.UTC(), token.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = int(id)
+	return nil
+}
+
+func (s *SQLRefreshTokenStore) GetRefreshTokenByHash(hash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	var revokedAt sql.NullTime
+	err := s.DB.QueryRow("SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = ?", hash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &revokedAt, &token.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return token, ErrNotFound
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, err
+}
+
+func (s *SQLRefreshTokenStore) RevokeRefreshToken(id int, at time.Time) (bool, error) {
+	res, err := s.DB.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", at.UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *SQLRefreshTokenStore) RevokeRefreshTokenFamily(familyID string, at time.Time) error {
+	_, err := s.DB.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", at.UTC(), familyID)
+	return err
+}
+
+func (s *SQLRefreshTokenStore) RevokeUserRefreshTokens(userID int, at time.Time) error {
+	_, err := s.DB.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", at.UTC(), userID)
+	return err
+}
+
+type SQLRevocationStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLRevocationStore) RevokeToken(jti string, userID int, expiresAt time.Time) error {
+	_, err := s.DB.Exec("INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at) VALUES (?, ?, ?, ?)",
+		jti, userID, expiresAt.UTC(), time.Now().UTC())
+	return err
+}
+
+func (s *SQLRevocationStore) IsTokenRevoked(jti string, userID int, issuedAt time.Time) (bool, error) {
+	var count int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?", jti).Scan(&count)
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	var revokedAt time.Time
+	err = s.DB.QueryRow("SELECT revoked_at FROM session_revocations WHERE user_id = ?", userID).Scan(&revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !issuedAt.After(revokedAt), nil
+}
+
+func (s *SQLRevocationStore) RevokeUserSessions(userID int, at time.Time) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM session_revocations WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	// DATETIME saniye hassasiyetinde, yuvarlanıp geleceğe kaymasın
+	if _, err := tx.Exec("INSERT INTO session_revocations (user_id, revoked_at) VALUES (?, ?)", userID, at.UTC().Truncate(time.Second)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLRevocationStore) PruneRevokedTokens(now time.Time) (int64, error) {
+	res, err := s.DB.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type SQLRoleStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLRoleStore) ListRoles() ([]models.Role, error) {
+	rows, err := s.DB.Query("SELECT r.name, rp.permission FROM roles r LEFT JOIN role_permissions rp ON rp.role = r.name ORDER BY r.name, rp.permission")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	for rows.Next() {
+		var name string
+		var permission sql.NullString
+		if err := rows.Scan(&name, &permission); err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, models.Role{Name: name, Permissions: []string{}})
+		}
+		if permission.Valid {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, permission.String)
+		}
+	}
+	return roles, rows.Err()
+}
+
+func (s *SQLRoleStore) GetRole(name string) (models.Role, error) {
+	role := models.Role{Name: name, Permissions: []string{}}
+	var count int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM roles WHERE name = ?", name).Scan(&count); err != nil {
+		return role, err
+	}
+	if count == 0 {
+		return role, ErrNotFound
+	}
+
+	rows, err := s.DB.Query("SELECT permission FROM role_permissions WHERE role = ? ORDER BY permission", name)
+	if err != nil {
+		return role, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return role, err
+		}
+		role.Permissions = append(role.Permissions, permission)
+	}
+	return role, rows.Err()
+}
+
+func (s *SQLRoleStore) CreateRole(role models.Role) error {
+	if _, err := s.GetRole(role.Name); err == nil {
+		return ErrConflict
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO roles (name) VALUES (?)", role.Name); err != nil {
+		return err
+	}
+	if err := insertRolePermissions(tx, role.Name, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLRoleStore) SetRolePermissions(name string, permissions []string) error {
+	if _, err := s.GetRole(name); err != nil {
+		return err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role = ?", name); err != nil {
+		return err
+	}
+	if err := insertRolePermissions(tx, name, permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertRolePermissions(tx *sql.Tx, name string, permissions []string) error {
+	for _, permission := range permissions {
+		if _, err := tx.Exec("INSERT INTO role_permissions (role, permission) VALUES (?, ?)", name, permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLRoleStore) DeleteRole(name string) error {
+	if _, err := s.GetRole(name); err != nil {
+		return err
+	}
+
+	var users int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?", name).Scan(&users); err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrConflict
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role = ?", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM roles WHERE name = ?", name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type SQLTaskHistoryStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLTaskHistoryStore) ListTaskHistory(taskID int) ([]models.TaskHistoryEntry, error) {
+	rows, err := s.DB.Query("SELECT id, task_id, actor_id, action, field, old_value, new_value, created_at FROM task_history WHERE task_id = ? ORDER BY id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.TaskHistoryEntry
+	for rows.Next() {
+		var e models.TaskHistoryEntry
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.ActorID, &e.Action, &e.Field, &e.OldValue, &e.NewValue, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+type SQLTaskDependencyStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLTaskDependencyStore) AddTaskDependency(taskID, blockedBy int, at time.Time) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the blocker must not wait for the task already, checked in the
+	// transaction of the insert so that two requests cannot close a cycle
+	seen := map[int]bool{blockedBy: true}
+	for queue := []int{blockedBy}; len(queue) > 0; queue = queue[1:] {
+		blockers, err := listIDs(tx, "SELECT blocked_by FROM task_dependencies WHERE task_id = ?", queue[0])
+		if err != nil {
+			return err
+		}
+		for _, id := range blockers {
+			if id == taskID {
+				return ErrDependencyCycle
+			}
+			if !seen[id] {
+				seen[id] = true
+				queue = append(queue, id)
+			}
+		}
+	}
+
+	_, err = tx.Exec("INSERT INTO task_dependencies (task_id, blocked_by, created_at) VALUES (?, ?, ?)", taskID, blockedBy, at.UTC())
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLTaskDependencyStore) RemoveTaskDependency(taskID, blockedBy int) error {
+	return expectOneRow(s.DB.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocked_by = ?", taskID, blockedBy))
+}
+
+func (s *SQLTaskDependencyStore) ListBlockers(taskID int) ([]int, error) {
+	return listIDs(s.DB, "SELECT blocked_by FROM task_dependencies WHERE task_id = ? ORDER BY blocked_by", taskID)
+}
+
+func (s *SQLTaskDependencyStore) ListBlockedTasks(taskID int) ([]int, error) {
+	return listIDs(s.DB, "SELECT task_id FROM task_dependencies WHERE blocked_by = ? ORDER BY task_id", taskID)
+}
+
+// listIDs returns the IDs selected by the query, q is a *sql.DB or *sql.Tx.
+func listIDs(q interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}, query string, args ...interface{}) ([]int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+type SQLLabelStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLLabelStore) ListLabels(ownerID int) ([]models.Label, error) {
+	return s.listLabels("SELECT id, owner_id, name, color FROM labels WHERE owner_id = ? ORDER BY name", ownerID)
+}
+
+func (s *SQLLabelStore) GetLabel(id int) (models.Label, error) {
+	var label models.Label
+	err := s.DB.QueryRow("SELECT id, owner_id, name, color FROM labels WHERE id = ?", id).Scan(&label.ID, &label.OwnerID, &label.Name, &label.Color)
+	if errors.Is(err, sql.ErrNoRows) {
+		return label, ErrNotFound
+	}
+	return label, err
+}
+
+// nameTaken reports whether the owner has another label with the name.
+func (s *SQLLabelStore) nameTaken(label models.Label) (bool, error) {
+	var n int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM labels WHERE owner_id = ? AND name = ? AND id <> ?", label.OwnerID, label.Name, label.ID).Scan(&n)
+	return n > 0, err
+}
+
+func (s *SQLLabelStore) CreateLabel(label *models.Label) error {
+	if taken, err := s.nameTaken(*label); err != nil {
+		return err
+	} else if taken {
+		return ErrConflict
+	}
+	res, err := s.DB.Exec("INSERT INTO labels (owner_id, name, color) VALUES (?, ?, ?)", label.OwnerID, label.Name, label.Color)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	label.ID = int(id)
+	return nil
+}
+
+func (s *SQLLabelStore) UpdateLabel(label models.Label) error {
+	if taken, err := s.nameTaken(label); err != nil {
+		return err
+	} else if taken {
+		return ErrConflict
+	}
+	if _, err := s.GetLabel(label.ID); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec("UPDATE labels SET name = ?, color = ? WHERE id = ?", label.Name, label.Color, label.ID)
+	return err
+}
+
+func (s *SQLLabelStore) DeleteLabel(id int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", id); err != nil {
+		return err
+	}
+	if err := expectOneRow(tx.Exec("DELETE FROM labels WHERE id = ?", id)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLLabelStore) AddTaskLabel(taskID, labelID int) error {
+	var n int
+	if err := s.DB.QueryRow("SELECT COUNT(*) FROM task_labels WHERE task_id = ? AND label_id = ?", taskID, labelID).Scan(&n); err != nil || n > 0 {
+		return err
+	}
+	_, err := s.DB.Exec("INSERT INTO task_labels (task_id, label_id) VALUES (?, ?)", taskID, labelID)
+	return err
+}
+
+func (s *SQLLabelStore) RemoveTaskLabel(taskID, labelID int) error {
+	return expectOneRow(s.DB.Exec("DELETE FROM task_labels WHERE task_id = ? AND label_id = ?", taskID, labelID))
+}
+
+func (s *SQLLabelStore) ListTaskLabels(taskID, ownerID int) ([]models.Label, error) {
+	return s.listLabels("SELECT l.id, l.owner_id, l.name, l.color FROM labels l JOIN task_labels tl ON tl.label_id = l.id WHERE tl.task_id = ? AND l.owner_id = ? ORDER BY l.name", taskID, ownerID)
+}
+
+func (s *SQLLabelStore) listLabels(query string, args ...interface{}) ([]models.Label, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []models.Label
+	for rows.Next() {
+		var label models.Label
+		if err := rows.Scan(&label.ID, &label.OwnerID, &label.Name, &label.Color); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+type SQLCommentStore struct {
+	DB *sql.DB
+}
+
+const commentColumns = "id, task_id, author_id, body, created_at, updated_at"
+
+func scanComment(row interface{ Scan(...interface{}) error }) (models.Comment, error) {
+	var c models.Comment
+	err := row.Scan(&c.ID, &c.TaskID, &c.AuthorID, &c.Body, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+func (s *SQLCommentStore) CreateComment(comment *models.Comment, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		res, err := tx.Exec("INSERT INTO comments (task_id, author_id, body, created_at) VALUES (?, ?, ?, ?)",
+			comment.TaskID, comment.AuthorID, comment.Body, comment.CreatedAt.UTC())
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		comment.ID = int(id)
+		return nil
+	})
+}
+
+func (s *SQLCommentStore) GetComment(id int) (models.Comment, error) {
+	c, err := scanComment(s.DB.QueryRow("SELECT "+commentColumns+" FROM comments WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNotFound
+	}
+	return c, err
+}
+
+func (s *SQLCommentStore) ListComments(taskID int) ([]models.Comment, error) {
+	rows, err := s.DB.Query("SELECT "+commentColumns+" FROM comments WHERE task_id = ? ORDER BY id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func (s *SQLCommentStore) UpdateComment(id int, body string, at time.Time, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		var old string
+		err := tx.QueryRow("SELECT body FROM comments WHERE id = ?", id).Scan(&old)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO comment_edits (comment_id, body, edited_at) VALUES (?, ?, ?)", id, old, at.UTC()); err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE comments SET body = ?, updated_at = ? WHERE id = ?", body, at.UTC(), id)
+		return err
+	})
+}
+
+func (s *SQLCommentStore) DeleteComment(id int, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM comment_edits WHERE comment_id = ?", id); err != nil {
+			return err
+		}
+		return expectOneRow(tx.Exec("DELETE FROM comments WHERE id = ?", id))
+	})
+}
+
+func (s *SQLCommentStore) ListCommentEdits(commentID int) ([]models.CommentEdit, error) {
+	rows, err := s.DB.Query("SELECT id, comment_id, body, edited_at FROM comment_edits WHERE comment_id = ? ORDER BY id", commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []models.CommentEdit
+	for rows.Next() {
+		var e models.CommentEdit
+		if err := rows.Scan(&e.ID, &e.CommentID, &e.Body, &e.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+type SQLNotificationStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLNotificationStore) CreateNotification(n *models.Notification) error {
+	var eventID interface{}
+	if n.EventID != 0 {
+		eventID = n.EventID
+	}
+	// NULL event ids never collide, the unique key only catches an event
+	// notified twice
+	res, err := s.DB.Exec("INSERT INTO notifications (user_id, type, task_id, actor_id, message, event_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		n.UserID, n.Type, n.TaskID, n.ActorID, n.Message, eventID, n.CreatedAt.UTC())
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	n.ID = int(id)
+	return nil
+}
+
+func (s *SQLNotificationStore) ListNotifications(userID int, unreadOnly bool) ([]models.Notification, error) {
+	query := "SELECT id, user_id, type, task_id, actor_id, message, COALESCE(event_id, 0), created_at, read_at FROM notifications WHERE user_id = ?"
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	rows, err := s.DB.Query(query+" ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.TaskID, &n.ActorID, &n.Message, &n.EventID, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (s *SQLNotificationStore) CountUnreadNotifications(userID int) (int, error) {
+	var n int
+	err := s.DB.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&n)
+	return n, err
+}
+
+func (s *SQLNotificationStore) SetNotificationRead(id, userID int, readAt *time.Time) error {
+	var owner int
+	err := s.DB.QueryRow("SELECT user_id FROM notifications WHERE id = ?", id).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && owner != userID) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("UPDATE notifications SET read_at = ? WHERE id = ?", nullableUTC(readAt), id)
+	return err
+}
+
+func (s *SQLNotificationStore) MarkAllNotificationsRead(userID int, at time.Time) (int64, error) {
+	res, err := s.DB.Exec("UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL", at.UTC(), userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *SQLNotificationStore) GetNotificationPreferences(userID int) (map[string][]string, error) {
+	rows, err := s.DB.Query("SELECT type, channels FROM notification_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := map[string][]string{}
+	for rows.Next() {
+		var t, channels string
+		if err := rows.Scan(&t, &channels); err != nil {
+			return nil, err
+		}
+		prefs[t] = []string{}
+		if channels != "" {
+			prefs[t] = strings.Split(channels, ",")
+		}
+	}
+	return prefs, rows.Err()
+}
+
+func (s *SQLNotificationStore) SetNotificationPreferences(userID int, channels map[string][]string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM notification_preferences WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for t, c := range channels {
+		if _, err := tx.Exec("INSERT INTO notification_preferences (user_id, type, channels) VALUES (?, ?, ?)", userID, t, strings.Join(c, ",")); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type SQLAttachmentStore struct {
+	DB *sql.DB
+}
+
+const attachmentColumns = "id, task_id, uploader_id, filename, content_type, size, storage_key, created_at"
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.TaskID, &a.UploaderID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	return a, err
+}
+
+func (s *SQLAttachmentStore) CreateAttachment(a *models.Attachment) error {
+	res, err := s.DB.Exec("INSERT INTO attachments (task_id, uploader_id, filename, content_type, size, storage_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		a.TaskID, a.UploaderID, a.Filename, a.ContentType, a.Size, a.StorageKey, a.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.ID = int(id)
+	return nil
+}
+
+func (s *SQLAttachmentStore) GetAttachment(id int) (models.Attachment, error) {
+	a, err := scanAttachment(s.DB.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, ErrNotFound
+	}
+	return a, err
+}
+
+func (s *SQLAttachmentStore) ListAttachments(taskID int) ([]models.Attachment, error) {
+	return s.listAttachments("SELECT "+attachmentColumns+" FROM attachments WHERE task_id = ? ORDER BY id", taskID)
+}
+
+func (s *SQLAttachmentStore) DeleteAttachment(id int) error {
+	return expectOneRow(s.DB.Exec("DELETE FROM attachments WHERE id = ?", id))
+}
+
+func (s *SQLAttachmentStore) ListOrphanedAttachments() ([]models.Attachment, error) {
+	return s.listAttachments("SELECT " + attachmentColumns + " FROM attachments WHERE task_id NOT IN (SELECT id FROM tasks) ORDER BY id")
+}
+
+func (s *SQLAttachmentStore) listAttachments(query string, args ...interface{}) ([]models.Attachment, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+type SQLRecurrenceStore struct {
+	DB *sql.DB
+}
+
+const recurrenceColumns = "task_id, rule, timezone, series_start, last_task_id, next_start, created_at"
+
+func scanRecurrence(row interface{ Scan(...interface{}) error }) (models.TaskRecurrence, error) {
+	var rec models.TaskRecurrence
+	err := row.Scan(&rec.TaskID, &rec.Rule, &rec.Timezone, &rec.Start, &rec.LastTaskID, &rec.NextStart, &rec.CreatedAt)
+	return rec, err
+}
+
+// nullableUTC converts an optional time for a query argument.
+func nullableUTC(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func (s *SQLRecurrenceStore) SetRecurrence(rec models.TaskRecurrence) error {
+	_, err := s.GetRecurrence(rec.TaskID)
+	if errors.Is(err, ErrNotFound) {
+		_, err = s.DB.Exec("INSERT INTO task_recurrences ("+recurrenceColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+			rec.TaskID, rec.Rule, rec.Timezone, rec.Start.UTC(), rec.LastTaskID, nullableUTC(rec.NextStart), rec.CreatedAt.UTC())
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("UPDATE task_recurrences SET rule = ?, timezone = ?, series_start = ?, last_task_id = ?, next_start = ? WHERE task_id = ?",
+		rec.Rule, rec.Timezone, rec.Start.UTC(), rec.LastTaskID, nullableUTC(rec.NextStart), rec.TaskID)
+	return err
+}
+
+func (s *SQLRecurrenceStore) GetRecurrence(taskID int) (models.TaskRecurrence, error) {
+	rec, err := scanRecurrence(s.DB.QueryRow("SELECT "+recurrenceColumns+" FROM task_recurrences WHERE task_id = ?", taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return rec, ErrNotFound
+	}
+	return rec, err
+}
+
+func (s *SQLRecurrenceStore) DeleteRecurrence(taskID int) error {
+	return expectOneRow(s.DB.Exec("DELETE FROM task_recurrences WHERE task_id = ?", taskID))
+}
+
+func (s *SQLRecurrenceStore) ListActiveRecurrences() ([]models.TaskRecurrence, error) {
+	rows, err := s.DB.Query("SELECT " + recurrenceColumns + " FROM task_recurrences WHERE next_start IS NOT NULL ORDER BY task_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []models.TaskRecurrence
+	for rows.Next() {
+		rec, err := scanRecurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+func (s *SQLRecurrenceStore) CreateOccurrence(taskID int, occurrence *models.Task, nextStart *time.Time, event EventFunc) error {
+	return withEvent(s.DB, event, func(tx *sql.Tx) error {
+		if err := insertTask(tx, occurrence); err != nil {
+			return err
+		}
+		return expectOneRow(tx.Exec("UPDATE task_recurrences SET last_task_id = ?, next_start = ? WHERE task_id = ?", occurrence.ID, nullableUTC(nextStart), taskID))
+	})
+}
+
+type SQLReminderStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLReminderStore) GetReminderPreferences(userID int) (models.ReminderPreferences, error) {
+	prefs := models.ReminderPreferences{UserID: userID}
+	var leadHours string
+	err := s.DB.QueryRow("SELECT enabled, lead_hours, escalations FROM reminder_preferences WHERE user_id = ?", userID).Scan(&prefs.Enabled, &leadHours, &prefs.Escalations)
+	if errors.Is(err, sql.ErrNoRows) {
+		return prefs, ErrNotFound
+	}
+	if err != nil {
+		return prefs, err
+	}
+	prefs.LeadHours, err = splitInts(leadHours)
+	return prefs, err
+}
+
+func (s *SQLReminderStore) SetReminderPreferences(prefs models.ReminderPreferences) error {
+	leadHours := joinInts(prefs.LeadHours)
+	_, err := s.GetReminderPreferences(prefs.UserID)
+	if errors.Is(err, ErrNotFound) {
+		_, err = s.DB.Exec("INSERT INTO reminder_preferences (user_id, enabled, lead_hours, escalations) VALUES (?, ?, ?, ?)",
+			prefs.UserID, prefs.Enabled, leadHours, prefs.Escalations)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("UPDATE reminder_preferences SET enabled = ?, lead_hours = ?, escalations = ? WHERE user_id = ?",
+		prefs.Enabled, leadHours, prefs.Escalations, prefs.UserID)
+	return err
+}
+
+func (s *SQLReminderStore) ClaimReminder(d models.ReminderDelivery) error {
+	// the unique key decides between two workers claiming at the same time
+	_, err := s.DB.Exec("INSERT INTO reminder_deliveries (task_id, user_id, kind, due_date, created_at) VALUES (?, ?, ?, ?, ?)",
+		d.TaskID, d.UserID, d.Kind, d.DueDate.UTC(), d.CreatedAt.UTC())
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *SQLReminderStore) ReleaseReminder(d models.ReminderDelivery) error {
+	_, err := s.DB.Exec("DELETE FROM reminder_deliveries WHERE task_id = ? AND user_id = ? AND kind = ? AND due_date = ?",
+		d.TaskID, d.UserID, d.Kind, d.DueDate.UTC())
+	return err
+}
+
+func splitInts(s string) ([]int, error) {
+	var ints []int
+	for _, v := range strings.Split(s, ",") {
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+func joinInts(ints []int) string {
+	parts := make([]string, len(ints))
+	for i, n := range ints {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
+
+type SQLWebhookStore struct {
+	DB *sql.DB
+}
+
+const webhookColumns = "id, user_id, url, secret, events, active, created_at"
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (models.Webhook, error) {
+	var w models.Webhook
+	var events string
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &events, &w.Active, &w.CreatedAt)
+	w.Events = strings.Split(events, ",")
+	return w, err
+}
+
+func (s *SQLWebhookStore) CreateWebhook(w *models.Webhook) error {
+	res, err := s.DB.Exec("INSERT INTO webhooks (user_id, url, secret, events, active, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		w.UserID, w.URL, w.Secret, strings.Join(w.Events, ","), w.Active, w.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	w.ID = int(id)
+	return nil
+}
+
+func (s *SQLWebhookStore) GetWebhook(id int) (models.Webhook, error) {
+	w, err := scanWebhook(s.DB.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return w, ErrNotFound
+	}
+	return w, err
+}
+
+func (s *SQLWebhookStore) ListWebhooks(userID int) ([]models.Webhook, error) {
+	return s.listWebhooks("SELECT "+webhookColumns+" FROM webhooks WHERE user_id = ? ORDER BY id", userID)
+}
+
+func (s *SQLWebhookStore) ListWebhooksForEvent(eventType string) ([]models.Webhook, error) {
+	active, err := s.listWebhooks("SELECT "+webhookColumns+" FROM webhooks WHERE active = ? ORDER BY id", true)
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []models.Webhook
+	for _, w := range active {
+		for _, e := range w.Events {
+			if e == eventType {
+				webhooks = append(webhooks, w)
+				break
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+func (s *SQLWebhookStore) listWebhooks(query string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s *SQLWebhookStore) UpdateWebhook(w models.Webhook) error {
+	var exists int
+	err := s.DB.QueryRow("SELECT 1 FROM webhooks WHERE id = ?", w.ID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec("UPDATE webhooks SET url = ?, secret = ?, events = ?, active = ? WHERE id = ?",
+		w.URL, w.Secret, strings.Join(w.Events, ","), w.Active, w.ID)
+	return err
+}
+
+func (s *SQLWebhookStore) DeleteWebhook(id int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := expectOneRow(tx.Exec("DELETE FROM webhooks WHERE id = ?", id)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, response_status, last_error, next_attempt_at, last_attempt_at, created_at"
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload string
+	var next, last sql.NullTime
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.Error, &next, &last, &d.CreatedAt)
+	d.Payload = json.RawMessage(payload)
+	if next.Valid {
+		d.NextAttemptAt = &next.Time
+	}
+	if last.Valid {
+		d.LastAttemptAt = &last.Time
+	}
+	return d, err
+}
+
+func (s *SQLWebhookStore) CreateDelivery(d *models.WebhookDelivery) error {
+	res, err := s.DB.Exec("INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, status, attempts, response_status, last_error, next_attempt_at, last_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		d.WebhookID, d.EventID, d.EventType, string(d.Payload), d.Status, d.Attempts, d.ResponseStatus, d.Error, nullableUTC(d.NextAttemptAt), nullableUTC(d.LastAttemptAt), d.CreatedAt.UTC())
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.ID = int(id)
+	return nil
+}
+
+func (s *SQLWebhookStore) GetDelivery(id int) (models.WebhookDelivery, error) {
+	d, err := scanDelivery(s.DB.QueryRow("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, ErrNotFound
+	}
+	return d, err
+}
+
+func (s *SQLWebhookStore) ListDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error) {
+	return s.listDeliveries("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?", webhookID, limit)
+}
+
+func (s *SQLWebhookStore) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return s.listDeliveries("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?",
+		models.DeliveryPending, now.UTC(), limit)
+}
+
+func (s *SQLWebhookStore) listDeliveries(query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *SQLWebhookStore) UpdateDelivery(d models.WebhookDelivery) error {
+	_, err := s.DB.Exec("UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = ?, last_error = ?, next_attempt_at = ?, last_attempt_at = ? WHERE id = ?",
+		d.Status, d.Attempts, d.ResponseStatus, d.Error, nullableUTC(d.NextAttemptAt), nullableUTC(d.LastAttemptAt), d.ID)
+	return err
+}
+
+func (s *SQLWebhookStore) PurgeDeliveries(before time.Time) (int64, error) {
+	res, err := s.DB.Exec("DELETE FROM webhook_deliveries WHERE status <> ? AND created_at < ?", models.DeliveryPending, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type SQLOutboxStore struct {
+	DB *sql.DB
+}
+
+func (s *SQLOutboxStore) ListUnpublishedEvents(now time.Time, limit int) ([]models.Event, error) {
+	return s.listEvents("SELECT "+eventColumns+" FROM outbox_events WHERE published_at IS NULL AND failed_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= ?) ORDER BY id LIMIT ?",
+		now.UTC(), limit)
+}
+
+// SequenceEvents takes the next numbers from the highest one given so far, it
+// relies on a single dispatcher.
+func (s *SQLOutboxStore) SequenceEvents(events []models.Event) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var last int64
+	if err := tx.QueryRow("SELECT COALESCE(MAX(seq), 0) FROM outbox_events").Scan(&last); err != nil {
+		return err
+	}
+	for i := range events {
+		if events[i].Seq != 0 {
+			continue
+		}
+		last++
+		if _, err := tx.Exec("UPDATE outbox_events SET seq = ? WHERE id = ?", last, events[i].ID); err != nil {
+			return err
+		}
+		events[i].Seq = last
+	}
+	return tx.Commit()
+}
+
+func (s *SQLOutboxStore) MarkEventsPublished(ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := []interface{}{at.UTC()}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := s.DB.Exec("UPDATE outbox_events SET published_at = ? WHERE id IN (?"+strings.Repeat(", ?", len(ids)-1)+")", args...)
+	return err
+}
+
+func (s *SQLOutboxStore) MarkEventFailed(id int64, lastError string, next *time.Time, at time.Time) error {
+	var failedAt *time.Time
+	if next == nil {
+		failedAt = &at
+	}
+	_, err := s.DB.Exec("UPDATE outbox_events SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?, failed_at = ? WHERE id = ?",
+		lastError, nullableUTC(next), nullableUTC(failedAt), id)
+	return err
+}
+
+func (s *SQLOutboxStore) ListPublishedEvents(afterSeq int64, limit int) ([]models.Event, error) {
+	return s.listEvents("SELECT "+eventColumns+" FROM outbox_events WHERE seq > ? ORDER BY seq LIMIT ?", afterSeq, limit)
+}
+
+const eventColumns = "id, COALESCE(seq, 0), type, actor_id, data, created_at, attempts"
+
+func (s *SQLOutboxStore) listEvents(query string, args ...interface{}) ([]models.Event, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var ev models.Event
+		var data string
+		if err := rows.Scan(&ev.ID, &ev.Seq, &ev.Type, &ev.ActorID, &data, &ev.CreatedAt, &ev.Attempts); err != nil {
+			return nil, err
+		}
+		ev.Data = json.RawMessage(data)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLOutboxStore) PurgeEvents(before time.Time) (int64, error) {
+	res, err := s.DB.Exec("DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
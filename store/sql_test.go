@@ -0,0 +1,28 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'PRIMARY'"}, true},
+		{fmt.Errorf("insert: %w", &mysql.MySQLError{Number: 1062}), true},
+		{&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, false},
+		{errors.New("constraint failed: UNIQUE constraint failed: users.username (2067)"), true},
+		{errors.New("constraint failed: FOREIGN KEY constraint failed (787)"), false},
+	}
+	for _, tt := range tests {
+		if got := isUniqueViolation(tt.err); got != tt.want {
+			t.Errorf("isUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
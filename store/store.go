@@ -0,0 +1,265 @@
+package store
+
+import (
+	"errors"
+	"task-management-system/models"
+	"time"
+)
+
+// ErrNotFound is returned when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned when a unique value is already taken.
+var ErrConflict = errors.New("record already exists")
+
+// ErrSubtaskCycle is returned when a task would become its own ancestor.
+var ErrSubtaskCycle = errors.New("a task cannot be a subtask of itself or of its own subtasks")
+
+// ErrDependencyCycle is returned when a task would wait for itself.
+var ErrDependencyCycle = errors.New("the dependency would form a cycle")
+
+// EventFunc builds the event of a change once it is made, e.g. after a new
+// row got its ID, together with the task history entries of the change.
+// Stores record both in the same transaction as the change, see OutboxStore.
+// A nil EventFunc records nothing.
+type EventFunc func() (models.Event, []models.TaskHistoryEntry, error)
+
+type TaskStore interface {
+	CreateTask(task *models.Task, event EventFunc) error
+	// GetTask also returns tasks in the trash, see Task.DeletedAt.
+	GetTask(id int) (models.Task, error)
+	UpdateTask(task models.Task, event EventFunc) error
+	// MarkTaskOverdue sets the OverdueAt of the task unless it is set.
+	MarkTaskOverdue(id int, at time.Time) error
+	// TrashTask moves the task to the trash. It returns ErrNotFound when the
+	// task does not exist or is already in the trash.
+	TrashTask(id, deletedBy int, at time.Time, event EventFunc) error
+	// RestoreTask takes the task out of the trash. It returns ErrNotFound
+	// when the task is not in the trash.
+	RestoreTask(id int, event EventFunc) error
+	// PurgeTasks permanently deletes the tasks trashed before the given time.
+	PurgeTasks(before time.Time) (int64, error)
+	ListTasks(q TaskQuery) (TaskPage, error)
+	// CountTasksByStatus counts the tasks assigned to the user per status,
+	// leaving out the trash. leafOnly skips tasks that have subtasks.
+	CountTasksByStatus(userID int, leafOnly bool) (map[string]int, error)
+	// CountTasksByLabel counts the tasks assigned to the user per label ID
+	// the same way.
+	CountTasksByLabel(userID int, leafOnly bool) (map[int]int, error)
+	// CountTasksByPriority counts the tasks assigned to the user in one of
+	// the statuses per priority the same way.
+	CountTasksByPriority(userID int, statuses []string, leafOnly bool) (map[string]int, error)
+	// ListSubtasks returns the children of a task that are not in the trash.
+	ListSubtasks(parentID int) ([]models.Task, error)
+	// SetTaskParent makes the task a subtask of parentID, nil detaches it.
+	// It returns ErrSubtaskCycle when the task is parentID or one of its
+	// ancestors.
+	SetTaskParent(id int, parentID *int, event EventFunc) error
+}
+
+type UserStore interface {
+	// CreateUser returns ErrConflict when the username is taken.
+	CreateUser(user *models.User) error
+	GetUser(id int) (models.User, error)
+	GetUserByUsername(username string) (models.User, error)
+	// SetUserRole changes the role of the user. It returns ErrNotFound when
+	// the user does not exist.
+	SetUserRole(id int, role string) error
+}
+
+type FriendshipStore interface {
+	CreateFriendship(friendship *models.Friendship, event EventFunc) error
+	// GetFriendship returns the latest request sent by userID to friendID.
+	GetFriendship(userID, friendID int) (models.Friendship, error)
+	// UpdateFriendshipStatus changes the status of the request sent by userID to friendID.
+	UpdateFriendshipStatus(userID, friendID int, status string, event EventFunc) error
+	// AcceptFriendship accepts the pending request sent by friendship.UserID
+	// to friendship.FriendID and sets its ID and status. It returns
+	// ErrNotFound, and records no event, when no request is pending.
+	AcceptFriendship(friendship *models.Friendship, event EventFunc) error
+}
+
+type RefreshTokenStore interface {
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshTokenByHash(hash string) (models.RefreshToken, error)
+	// RevokeRefreshToken marks the token as used. It reports false when the
+	// token had already been revoked, e.g. by a concurrent refresh.
+	RevokeRefreshToken(id int, at time.Time) (bool, error)
+	RevokeRefreshTokenFamily(familyID string, at time.Time) error
+	RevokeUserRefreshTokens(userID int, at time.Time) error
+}
+
+type RevocationStore interface {
+	RevokeToken(jti string, userID int, expiresAt time.Time) error
+	// IsTokenRevoked reports whether the token itself was revoked or it was
+	// issued before every session of its user was revoked.
+	IsTokenRevoked(jti string, userID int, issuedAt time.Time) (bool, error)
+	RevokeUserSessions(userID int, at time.Time) error
+	// PruneRevokedTokens drops revoked tokens that have expired anyway.
+	PruneRevokedTokens(now time.Time) (int64, error)
+}
+
+type RoleStore interface {
+	ListRoles() ([]models.Role, error)
+	GetRole(name string) (models.Role, error)
+	// CreateRole returns ErrConflict when a role with the name exists.
+	CreateRole(role models.Role) error
+	SetRolePermissions(name string, permissions []string) error
+	// DeleteRole returns ErrConflict while users still have the role.
+	DeleteRole(name string) error
+}
+
+// TaskHistoryStore reads the task history. The entries are written by the
+// TaskStore with the changes they describe, see EventFunc.
+type TaskHistoryStore interface {
+	// ListTaskHistory returns the entries of a task, oldest first.
+	ListTaskHistory(taskID int) ([]models.TaskHistoryEntry, error)
+}
+
+type TaskDependencyStore interface {
+	// AddTaskDependency returns ErrConflict when the dependency exists and
+	// ErrDependencyCycle when blockedBy waits for the task, directly or
+	// through other tasks.
+	AddTaskDependency(taskID, blockedBy int, at time.Time) error
+	RemoveTaskDependency(taskID, blockedBy int) error
+	// ListBlockers returns the IDs of the tasks the task waits for.
+	ListBlockers(taskID int) ([]int, error)
+	// ListBlockedTasks returns the IDs of the tasks waiting for the task.
+	ListBlockedTasks(taskID int) ([]int, error)
+}
+
+type LabelStore interface {
+	ListLabels(ownerID int) ([]models.Label, error)
+	GetLabel(id int) (models.Label, error)
+	// CreateLabel and UpdateLabel return ErrConflict when the owner already
+	// has a label with the name.
+	CreateLabel(label *models.Label) error
+	UpdateLabel(label models.Label) error
+	// DeleteLabel also removes the label from every task.
+	DeleteLabel(id int) error
+	// AddTaskLabel does nothing when the task already has the label.
+	AddTaskLabel(taskID, labelID int) error
+	RemoveTaskLabel(taskID, labelID int) error
+	ListTaskLabels(taskID, ownerID int) ([]models.Label, error)
+}
+
+type CommentStore interface {
+	CreateComment(comment *models.Comment, event EventFunc) error
+	GetComment(id int) (models.Comment, error)
+	// ListComments returns the comments of a task, oldest first.
+	ListComments(taskID int) ([]models.Comment, error)
+	// UpdateComment saves the new body and keeps the old one as an edit.
+	UpdateComment(id int, body string, at time.Time, event EventFunc) error
+	// DeleteComment also deletes the edits of the comment.
+	DeleteComment(id int, event EventFunc) error
+	ListCommentEdits(commentID int) ([]models.CommentEdit, error)
+}
+
+type NotificationStore interface {
+	// CreateNotification returns ErrConflict when the user already has a
+	// notification of n.EventID.
+	CreateNotification(n *models.Notification) error
+	// ListNotifications returns the notifications of the user, newest first.
+	ListNotifications(userID int, unreadOnly bool) ([]models.Notification, error)
+	CountUnreadNotifications(userID int) (int, error)
+	// SetNotificationRead marks a notification of the user as read at the
+	// given time, or as unread for nil. It returns ErrNotFound for
+	// notifications of other users.
+	SetNotificationRead(id, userID int, readAt *time.Time) error
+	// MarkAllNotificationsRead marks every unread notification of the user.
+	MarkAllNotificationsRead(userID int, at time.Time) (int64, error)
+	// GetNotificationPreferences returns the channels the user chose per
+	// notification type, types without a choice are left out.
+	GetNotificationPreferences(userID int) (map[string][]string, error)
+	// SetNotificationPreferences replaces the choices of the user.
+	SetNotificationPreferences(userID int, channels map[string][]string) error
+}
+
+type AttachmentStore interface {
+	CreateAttachment(a *models.Attachment) error
+	GetAttachment(id int) (models.Attachment, error)
+	// ListAttachments returns the attachments of a task, oldest first.
+	ListAttachments(taskID int) ([]models.Attachment, error)
+	DeleteAttachment(id int) error
+	// ListOrphanedAttachments returns the attachments of purged tasks, whose
+	// blobs still have to be deleted.
+	ListOrphanedAttachments() ([]models.Attachment, error)
+}
+
+type RecurrenceStore interface {
+	// SetRecurrence creates the recurrence of the task or replaces it.
+	SetRecurrence(rec models.TaskRecurrence) error
+	GetRecurrence(taskID int) (models.TaskRecurrence, error)
+	DeleteRecurrence(taskID int) error
+	// ListActiveRecurrences returns the recurrences that have a next start.
+	ListActiveRecurrences() ([]models.TaskRecurrence, error)
+	// CreateOccurrence creates the task of a new occurrence of the series of
+	// taskID and records it with the start of the one after it, nil when the
+	// series has ended, in one transaction. It returns ErrNotFound when the
+	// task does not repeat.
+	CreateOccurrence(taskID int, occurrence *models.Task, nextStart *time.Time, event EventFunc) error
+}
+
+// OutboxStore holds the events recorded with the changes they describe until
+// they are published.
+type OutboxStore interface {
+	// ListUnpublishedEvents returns up to limit events not published yet
+	// that are due at now, oldest first. Events waiting for a retry and
+	// failed events are left out.
+	ListUnpublishedEvents(now time.Time, limit int) ([]models.Event, error)
+	// SequenceEvents gives the events without a sequence number the next
+	// ones, in the order of the slice, and sets their Seq.
+	SequenceEvents(events []models.Event) error
+	MarkEventsPublished(ids []int64, at time.Time) error
+	// MarkEventFailed records a failed publish of the event. It is retried
+	// at next, or kept as a failed event and not published again when next
+	// is nil.
+	MarkEventFailed(id int64, lastError string, next *time.Time, at time.Time) error
+	// ListPublishedEvents returns up to limit events handed to subscribers
+	// with a sequence number above afterSeq, in sequence order.
+	ListPublishedEvents(afterSeq int64, limit int) ([]models.Event, error)
+	// PurgeEvents deletes the events published before the given time. Failed
+	// events are kept.
+	PurgeEvents(before time.Time) (int64, error)
+}
+
+type WebhookStore interface {
+	CreateWebhook(w *models.Webhook) error
+	GetWebhook(id int) (models.Webhook, error)
+	// ListWebhooks returns the webhooks of the user, oldest first.
+	ListWebhooks(userID int) ([]models.Webhook, error)
+	// ListWebhooksForEvent returns the active webhooks subscribed to the
+	// event type.
+	ListWebhooksForEvent(eventType string) ([]models.Webhook, error)
+	UpdateWebhook(w models.Webhook) error
+	// DeleteWebhook deletes the webhook together with its deliveries.
+	DeleteWebhook(id int) error
+
+	// CreateDelivery returns ErrConflict when the webhook already has a
+	// delivery of d.EventID.
+	CreateDelivery(d *models.WebhookDelivery) error
+	GetDelivery(id int) (models.WebhookDelivery, error)
+	// ListDeliveries returns the latest deliveries of the webhook, newest
+	// first.
+	ListDeliveries(webhookID, limit int) ([]models.WebhookDelivery, error)
+	// ListDueDeliveries returns the pending deliveries whose next attempt is
+	// due at now, oldest first.
+	ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	UpdateDelivery(d models.WebhookDelivery) error
+	// PurgeDeliveries deletes the finished deliveries created before the
+	// given time.
+	PurgeDeliveries(before time.Time) (int64, error)
+}
+
+type ReminderStore interface {
+	// GetReminderPreferences returns ErrNotFound for users who never saved
+	// their preferences.
+	GetReminderPreferences(userID int) (models.ReminderPreferences, error)
+	SetReminderPreferences(prefs models.ReminderPreferences) error
+	// ClaimReminder records a delivery before it is sent. It returns
+	// ErrConflict when the same delivery was already recorded.
+	ClaimReminder(d models.ReminderDelivery) error
+	// ReleaseReminder removes the claim of a delivery that could not be
+	// sent, so it is claimed again on the next run.
+	ReleaseReminder(d models.ReminderDelivery) error
+}
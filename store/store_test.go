@@ -0,0 +1,474 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"task-management-system/migrations"
+	"task-management-system/models"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// stores is one implementation of the stores under test. The tests below
+// run against every implementation, so the memory stores used by handler
+// tests behave like the SQL ones.
+type stores struct {
+	Tasks         TaskStore
+	History       TaskHistoryStore
+	Users         UserStore
+	Friendships   FriendshipStore
+	Outbox        OutboxStore
+	Revocations   RevocationStore
+	Dependencies  TaskDependencyStore
+	Labels        LabelStore
+	Comments      CommentStore
+	Reminders     ReminderStore
+	Notifications NotificationStore
+	Webhooks      WebhookStore
+}
+
+func memoryStores(t *testing.T) stores {
+	outbox := NewMemoryOutboxStore()
+	history := NewMemoryTaskHistoryStore()
+	labels := NewMemoryLabelStore()
+	tasks := NewMemoryTaskStore()
+	tasks.Events, tasks.History, tasks.Labels = outbox, history, labels
+	friendships := NewMemoryFriendshipStore()
+	friendships.Events = outbox
+	comments := NewMemoryCommentStore()
+	comments.Events = outbox
+	return stores{Tasks: tasks, History: history, Users: NewMemoryUserStore(), Friendships: friendships, Outbox: outbox,
+		Revocations: NewMemoryRevocationStore(), Dependencies: NewMemoryTaskDependencyStore(), Labels: labels, Comments: comments,
+		Reminders: NewMemoryReminderStore(), Notifications: NewMemoryNotificationStore(),
+		Webhooks: NewMemoryWebhookStore()}
+}
+
+// sqlStores migrates a new in-memory SQLite database.
+func sqlStores(t *testing.T) stores {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	m, err := migrations.New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return stores{
+		Tasks:         &SQLTaskStore{DB: db},
+		History:       &SQLTaskHistoryStore{DB: db},
+		Users:         &SQLUserStore{DB: db},
+		Friendships:   &SQLFriendshipStore{DB: db},
+		Outbox:        &SQLOutboxStore{DB: db},
+		Revocations:   &SQLRevocationStore{DB: db},
+		Dependencies:  &SQLTaskDependencyStore{DB: db},
+		Labels:        &SQLLabelStore{DB: db},
+		Comments:      &SQLCommentStore{DB: db},
+		Reminders:     &SQLReminderStore{DB: db},
+		Notifications: &SQLNotificationStore{DB: db},
+		Webhooks:      &SQLWebhookStore{DB: db},
+	}
+}
+
+func forEachStore(t *testing.T, test func(t *testing.T, s stores)) {
+	for name, open := range map[string]func(*testing.T) stores{"memory": memoryStores, "sql": sqlStores} {
+		t.Run(name, func(t *testing.T) { test(t, open(t)) })
+	}
+}
+
+func newUser(t *testing.T, s stores, username string) models.User {
+	t.Helper()
+	user := models.User{Username: username, Password: "hash", Role: "user", Email: username + "@example.com"}
+	if err := s.Users.CreateUser(&user); err != nil {
+		t.Fatalf("CreateUser(%s): %v", username, err)
+	}
+	return user
+}
+
+var day = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+func newTask(t *testing.T, s stores, title string, owner, assignee int, status string) models.Task {
+	t.Helper()
+	task := models.Task{Title: title, Status: status, Priority: "medium", StartDate: day, DueDate: day.AddDate(0, 0, 7), UserID: owner, AssignedTo: assignee}
+	if err := s.Tasks.CreateTask(&task, nil); err != nil {
+		t.Fatalf("CreateTask(%s): %v", title, err)
+	}
+	return task
+}
+
+// taskEvent records a task event with one history entry, the way the
+// handlers do.
+func taskEvent(typ string, task *models.Task, action string) EventFunc {
+	return func() (models.Event, []models.TaskHistoryEntry, error) {
+		data, err := json.Marshal(models.TaskEvent{Task: *task})
+		ev := models.Event{Type: typ, ActorID: task.UserID, CreatedAt: day, Data: data}
+		entry := models.TaskHistoryEntry{TaskID: task.ID, ActorID: task.UserID, Action: action, CreatedAt: day}
+		return ev, []models.TaskHistoryEntry{entry}, err
+	}
+}
+
+func titles(tasks []models.Task) []string {
+	out := []string{}
+	for _, task := range tasks {
+		out = append(out, task.Title)
+	}
+	return out
+}
+
+func TestUserStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		if alice.ID == 0 {
+			t.Fatal("CreateUser did not set the ID")
+		}
+
+		got, err := s.Users.GetUser(alice.ID)
+		if err != nil || got != alice {
+			t.Errorf("GetUser = %+v, %v, want %+v", got, err, alice)
+		}
+		got, err = s.Users.GetUserByUsername("alice")
+		if err != nil || got != alice {
+			t.Errorf("GetUserByUsername = %+v, %v, want %+v", got, err, alice)
+		}
+
+		dup := models.User{Username: "alice", Password: "hash", Role: "user"}
+		if err := s.Users.CreateUser(&dup); !errors.Is(err, ErrConflict) {
+			t.Errorf("CreateUser with a taken username = %v, want ErrConflict", err)
+		}
+		if _, err := s.Users.GetUser(alice.ID + 100); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetUser of a missing user = %v, want ErrNotFound", err)
+		}
+		if _, err := s.Users.GetUserByUsername("bob"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetUserByUsername of a missing user = %v, want ErrNotFound", err)
+		}
+
+		if err := s.Users.SetUserRole(alice.ID, "admin"); err != nil {
+			t.Fatal(err)
+		}
+		// the role does not change, which MySQL reports as no affected rows
+		if err := s.Users.SetUserRole(alice.ID, "admin"); err != nil {
+			t.Errorf("SetUserRole to the same role = %v", err)
+		}
+		if got, _ := s.Users.GetUser(alice.ID); got.Role != "admin" {
+			t.Errorf("Role = %q after SetUserRole", got.Role)
+		}
+		if err := s.Users.SetUserRole(alice.ID+100, "admin"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetUserRole of a missing user = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestTaskStoreCRUD(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice, bob := newUser(t, s, "alice"), newUser(t, s, "bob")
+
+		task := models.Task{Title: "Write tests", Description: "for the stores", Status: "todo", Priority: "high",
+			StartDate: day, DueDate: day.AddDate(0, 0, 3), UserID: alice.ID, AssignedTo: bob.ID}
+		if err := s.Tasks.CreateTask(&task, taskEvent(models.EventTaskCreated, &task, models.HistoryCreated)); err != nil {
+			t.Fatal(err)
+		}
+		if task.ID == 0 {
+			t.Fatal("CreateTask did not set the ID")
+		}
+		got, err := s.Tasks.GetTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, task) {
+			t.Errorf("GetTask = %+v, want %+v", got, task)
+		}
+		if _, err := s.Tasks.GetTask(task.ID + 100); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetTask of a missing task = %v, want ErrNotFound", err)
+		}
+
+		// UpdateTask never moves the task to another creator
+		update := task
+		update.Title, update.Status, update.UserID = "Write more tests", "in_progress", bob.ID
+		if err := s.Tasks.UpdateTask(update, taskEvent(models.EventTaskUpdated, &update, models.HistoryUpdated)); err != nil {
+			t.Fatal(err)
+		}
+		got, _ = s.Tasks.GetTask(task.ID)
+		if got.Title != "Write more tests" || got.Status != "in_progress" || got.UserID != alice.ID {
+			t.Errorf("after UpdateTask = %+v", got)
+		}
+
+		if err := s.Tasks.TrashTask(task.ID, bob.ID, day, taskEvent(models.EventTaskDeleted, &task, models.HistoryDeleted)); err != nil {
+			t.Fatal(err)
+		}
+		got, _ = s.Tasks.GetTask(task.ID)
+		if got.DeletedAt == nil || !got.DeletedAt.Equal(day) || got.DeletedBy == nil || *got.DeletedBy != bob.ID {
+			t.Errorf("after TrashTask DeletedAt = %v, DeletedBy = %v", got.DeletedAt, got.DeletedBy)
+		}
+		if err := s.Tasks.TrashTask(task.ID, bob.ID, day, nil); !errors.Is(err, ErrNotFound) {
+			t.Errorf("TrashTask of a trashed task = %v, want ErrNotFound", err)
+		}
+		if err := s.Tasks.RestoreTask(task.ID, taskEvent(models.EventTaskRestored, &task, models.HistoryRestored)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ = s.Tasks.GetTask(task.ID); got.DeletedAt != nil || got.DeletedBy != nil {
+			t.Errorf("after RestoreTask DeletedAt = %v, DeletedBy = %v", got.DeletedAt, got.DeletedBy)
+		}
+		if err := s.Tasks.RestoreTask(task.ID, nil); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RestoreTask of a live task = %v, want ErrNotFound", err)
+		}
+
+		history, err := s.History.ListTaskHistory(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actions []string
+		for _, e := range history {
+			actions = append(actions, e.Action)
+		}
+		want := []string{models.HistoryCreated, models.HistoryUpdated, models.HistoryDeleted, models.HistoryRestored}
+		if !reflect.DeepEqual(actions, want) {
+			t.Errorf("history = %v, want %v", actions, want)
+		}
+
+		events, err := s.Outbox.ListUnpublishedEvents(day, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var types []string
+		for _, ev := range events {
+			types = append(types, ev.Type)
+		}
+		want = []string{models.EventTaskCreated, models.EventTaskUpdated, models.EventTaskDeleted, models.EventTaskRestored}
+		if !reflect.DeepEqual(types, want) {
+			t.Errorf("events = %v, want %v", types, want)
+		}
+	})
+}
+
+func TestTaskStoreFailedEvent(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		fail := errors.New("no event")
+		task := models.Task{Title: "Lost", Status: "todo", Priority: "low", StartDate: day, DueDate: day, UserID: alice.ID, AssignedTo: alice.ID}
+		err := s.Tasks.CreateTask(&task, func() (models.Event, []models.TaskHistoryEntry, error) {
+			return models.Event{}, nil, fail
+		})
+		if !errors.Is(err, fail) {
+			t.Fatalf("CreateTask = %v, want the error of the event", err)
+		}
+		page, err := s.Tasks.ListTasks(TaskQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 0 {
+			t.Errorf("a task was created without its event: %v", titles(page.Tasks))
+		}
+	})
+}
+
+func TestTaskStoreListTasks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice, bob, carol := newUser(t, s, "alice"), newUser(t, s, "bob"), newUser(t, s, "carol")
+		newTask(t, s, "a", alice.ID, alice.ID, "todo")
+		newTask(t, s, "b", alice.ID, bob.ID, "done")
+		newTask(t, s, "c", bob.ID, bob.ID, "todo")
+		trashed := newTask(t, s, "d", alice.ID, alice.ID, "todo")
+		newTask(t, s, "e", carol.ID, carol.ID, "in_progress")
+		if err := s.Tasks.TrashTask(trashed.ID, alice.ID, day, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name  string
+			query TaskQuery
+			want  []string
+		}{
+			{"all live tasks", TaskQuery{}, []string{"a", "b", "c", "e"}},
+			{"visible to", TaskQuery{VisibleTo: bob.ID}, []string{"b", "c"}},
+			{"statuses", TaskQuery{Statuses: []string{"todo", "in_progress"}}, []string{"a", "c", "e"}},
+			{"assigned to", TaskQuery{AssignedTo: bob.ID}, []string{"b", "c"}},
+			{"created by", TaskQuery{CreatedBy: alice.ID}, []string{"a", "b"}},
+			{"trash", TaskQuery{Trashed: true}, []string{"d"}},
+			{"descending", TaskQuery{Sort: TaskSort{Key: "id", Desc: true}}, []string{"e", "c", "b", "a"}},
+			{"by status", TaskQuery{Sort: TaskSort{Key: "status"}}, []string{"b", "e", "a", "c"}},
+		}
+		for _, tt := range tests {
+			page, err := s.Tasks.ListTasks(tt.query)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if got := titles(page.Tasks); !reflect.DeepEqual(got, tt.want) || page.Total != len(tt.want) {
+				t.Errorf("%s: got %v (total %d), want %v", tt.name, got, page.Total, tt.want)
+			}
+		}
+
+		// sayfalar imleçle birbirini izler, toplam her sayfada aynı kalır
+		var got []string
+		q := TaskQuery{Limit: 3}
+		for {
+			page, err := s.Tasks.ListTasks(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 4 {
+				t.Errorf("Total = %d on a page, want 4", page.Total)
+			}
+			got = append(got, titles(page.Tasks)...)
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		if want := []string{"a", "b", "c", "e"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("pages = %v, want %v", got, want)
+		}
+		if _, err := s.Tasks.ListTasks(TaskQuery{Cursor: "garbage"}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ListTasks with a bad cursor = %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+func TestTaskStoreSubtasksAndCounts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		parent := newTask(t, s, "parent", alice.ID, alice.ID, "in_progress")
+		child1 := newTask(t, s, "child 1", alice.ID, alice.ID, "done")
+		child2 := newTask(t, s, "child 2", alice.ID, alice.ID, "todo")
+		for _, child := range []models.Task{child1, child2} {
+			if err := s.Tasks.SetTaskParent(child.ID, &parent.ID, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		subtasks, err := s.Tasks.ListSubtasks(parent.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(subtasks); !reflect.DeepEqual(got, []string{"child 1", "child 2"}) {
+			t.Errorf("ListSubtasks = %v", got)
+		}
+
+		counts, err := s.Tasks.CountTasksByStatus(alice.ID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"in_progress": 1, "done": 1, "todo": 1}; !reflect.DeepEqual(counts, want) {
+			t.Errorf("CountTasksByStatus = %v, want %v", counts, want)
+		}
+		counts, err = s.Tasks.CountTasksByStatus(alice.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"done": 1, "todo": 1}; !reflect.DeepEqual(counts, want) {
+			t.Errorf("CountTasksByStatus of leaves = %v, want %v", counts, want)
+		}
+
+		// a trashed parent is purged and its children become top-level tasks
+		if err := s.Tasks.TrashTask(parent.ID, alice.ID, day, nil); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := s.Tasks.PurgeTasks(day.Add(time.Second)); err != nil || n != 1 {
+			t.Fatalf("PurgeTasks = %d, %v, want 1", n, err)
+		}
+		if _, err := s.Tasks.GetTask(parent.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetTask of a purged task = %v, want ErrNotFound", err)
+		}
+		if got, _ := s.Tasks.GetTask(child1.ID); got.ParentID != nil {
+			t.Errorf("ParentID = %d after the parent was purged", *got.ParentID)
+		}
+	})
+}
+
+func TestSetTaskParentRejectsCycles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		root := newTask(t, s, "root", alice.ID, alice.ID, "pending")
+		mid := newTask(t, s, "mid", alice.ID, alice.ID, "pending")
+		leaf := newTask(t, s, "leaf", alice.ID, alice.ID, "pending")
+		for _, link := range [][2]models.Task{{mid, root}, {leaf, mid}} {
+			parentID := link[1].ID
+			if err := s.Tasks.SetTaskParent(link[0].ID, &parentID, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the stores check again what the handlers checked before
+		for _, link := range [][2]models.Task{{root, root}, {root, mid}, {root, leaf}, {mid, leaf}} {
+			parentID := link[1].ID
+			err := s.Tasks.SetTaskParent(link[0].ID, &parentID, taskEvent(models.EventTaskUpdated, &link[0], "updated"))
+			if !errors.Is(err, ErrSubtaskCycle) {
+				t.Errorf("%s under %s: %v, want ErrSubtaskCycle", link[0].Title, link[1].Title, err)
+			}
+		}
+		if events, _ := s.Outbox.ListUnpublishedEvents(day, 10); len(events) != 0 {
+			t.Errorf("the refused changes recorded %d events", len(events))
+		}
+		if err := s.Tasks.SetTaskParent(leaf.ID, &root.ID, nil); err != nil {
+			t.Errorf("moving leaf under root: %v", err)
+		}
+		if err := s.Tasks.SetTaskParent(mid.ID, nil, nil); err != nil {
+			t.Errorf("detaching mid: %v", err)
+		}
+	})
+}
+
+func TestFriendshipStore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice, bob := newUser(t, s, "alice"), newUser(t, s, "bob")
+		if _, err := s.Friendships.GetFriendship(alice.ID, bob.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetFriendship without a request = %v, want ErrNotFound", err)
+		}
+
+		request := models.Friendship{UserID: alice.ID, FriendID: bob.ID, Status: "pending"}
+		if err := s.Friendships.CreateFriendship(&request, nil); err != nil {
+			t.Fatal(err)
+		}
+		if request.ID == 0 {
+			t.Fatal("CreateFriendship did not set the ID")
+		}
+		got, err := s.Friendships.GetFriendship(alice.ID, bob.ID)
+		if err != nil || got != request {
+			t.Errorf("GetFriendship = %+v, %v, want %+v", got, err, request)
+		}
+		if _, err := s.Friendships.GetFriendship(bob.ID, alice.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetFriendship in the other direction = %v, want ErrNotFound", err)
+		}
+
+		accepted := models.Friendship{UserID: alice.ID, FriendID: bob.ID}
+		event := func() (models.Event, []models.TaskHistoryEntry, error) {
+			data, err := json.Marshal(accepted)
+			return models.Event{Type: models.EventFriendshipAccepted, ActorID: bob.ID, CreatedAt: day, Data: data}, nil, err
+		}
+		if err := s.Friendships.AcceptFriendship(&accepted, event); err != nil {
+			t.Fatal(err)
+		}
+		if accepted.ID != request.ID || accepted.Status != "accepted" {
+			t.Errorf("AcceptFriendship set %+v", accepted)
+		}
+		if got, _ := s.Friendships.GetFriendship(alice.ID, bob.ID); got.Status != "accepted" {
+			t.Errorf("Status = %q after AcceptFriendship", got.Status)
+		}
+		again := models.Friendship{UserID: alice.ID, FriendID: bob.ID}
+		if err := s.Friendships.AcceptFriendship(&again, event); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second AcceptFriendship = %v, want ErrNotFound", err)
+		}
+		events, err := s.Outbox.ListUnpublishedEvents(day, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Type != models.EventFriendshipAccepted {
+			t.Errorf("events = %+v, want one friendship.accepted", events)
+		}
+
+		if err := s.Friendships.UpdateFriendshipStatus(alice.ID, bob.ID, "rejected", nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.Friendships.GetFriendship(alice.ID, bob.ID); got.Status != "rejected" {
+			t.Errorf("Status = %q after UpdateFriendshipStatus", got.Status)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"task-management-system/models"
+	"time"
+)
+
+const (
+	DefaultTaskLimit = 50
+	MaxTaskLimit     = 200
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded or was issued
+// for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TaskQuery selects one page of tasks. Zero values mean "no filter".
+type TaskQuery struct {
+	// VisibleTo limits the result to tasks the user created or is assigned to.
+	VisibleTo  int
+	Statuses   []string
+	Priorities []string
+	AssignedTo int
+	CreatedBy  int
+	DueFrom    time.Time
+	DueTo      time.Time
+	StartFrom  time.Time
+	StartTo    time.Time
+	// LabelIDs keeps the tasks that have at least one of the labels.
+	LabelIDs []int
+	// LeafOnly skips tasks that have subtasks.
+	LeafOnly bool
+	// Trashed lists the tasks in the trash instead of the live ones.
+	Trashed bool
+	// Overdue keeps the tasks marked overdue, see Task.OverdueAt.
+	Overdue bool
+
+	Sort   TaskSort
+	Limit  int
+	Cursor string
+}
+
+type TaskPage struct {
+	Tasks      []models.Task
+	NextCursor string
+	// Total counts every task matching the filters, ignoring the cursor and limit.
+	Total int
+}
+
+// TaskSort is a sort key with an optional "-" prefix for descending order.
+// The task ID is always used as the tie breaker.
+type TaskSort struct {
+	Key  string
+	Desc bool
+}
+
+var taskSortKeys = map[string]bool{"id": true, "title": true, "status": true, "priority": true, "start_date": true, "due_date": true}
+
+func ParseTaskSort(s string) (TaskSort, error) {
+	if s == "" {
+		return TaskSort{Key: "id"}, nil
+	}
+	sort := TaskSort{Key: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+	if !taskSortKeys[sort.Key] {
+		return sort, fmt.Errorf("unknown sort key %q", sort.Key)
+	}
+	return sort, nil
+}
+
+func (s TaskSort) String() string {
+	if s.Desc {
+		return "-" + s.Key
+	}
+	return s.Key
+}
+
+// taskCursor points just after the last task of a page.
+type taskCursor struct {
+	Sort  string `json:"s"`
+	ID    int    `json:"id"`
+	Value string `json:"v,omitempty"`
+}
+
+func sortValue(task models.Task, key string) string {
+	switch key {
+	case "title":
+		return task.Title
+	case "status":
+		return task.Status
+	case "priority":
+		return task.Priority
+	case "start_date":
+		return task.StartDate.UTC().Format(time.RFC3339Nano)
+	case "due_date":
+		return task.DueDate.UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+func encodeTaskCursor(sort TaskSort, task models.Task) string {
+	b, _ := json.Marshal(taskCursor{Sort: sort.String(), ID: task.ID, Value: sortValue(task, sort.Key)})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeTaskCursor(sort TaskSort, s string) (taskCursor, error) {
+	var c taskCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort != sort.String() {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// cursorArg converts the cursor value back to the type of the sort column.
+func (c taskCursor) arg(key string) (interface{}, error) {
+	switch key {
+	case "start_date", "due_date":
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t.UTC(), nil
+	case "priority":
+		return models.PriorityRank(c.Value), nil
+	}
+	return c.Value, nil
+}
+
+// sort defaults to ordering by ID for queries built without ParseTaskSort.
+func (q TaskQuery) sort() TaskSort {
+	if q.Sort.Key == "" {
+		return TaskSort{Key: "id", Desc: q.Sort.Desc}
+	}
+	return q.Sort
+}
+
+func (q TaskQuery) limit() int {
+	if q.Limit <= 0 {
+		return DefaultTaskLimit
+	}
+	if q.Limit > MaxTaskLimit {
+		return MaxTaskLimit
+	}
+	return q.Limit
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"task-management-system/models"
+	"testing"
+	"time"
+)
+
+// taskIDs returns the IDs of the tasks in order.
+func taskIDs(tasks []models.Task) []int {
+	ids := []int{}
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+// allPages follows the cursors of q and returns the IDs of every page.
+func allPages(t *testing.T, s stores, q TaskQuery) []int {
+	t.Helper()
+	var ids []int
+	for pages := 0; ; pages++ {
+		if pages > 50 {
+			t.Fatal("the cursors do not end")
+		}
+		page, err := s.Tasks.ListTasks(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, taskIDs(page.Tasks)...)
+		if page.NextCursor == "" {
+			return ids
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func TestListTasksPagesAcrossEqualSortKeys(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		// most tasks share each sort value, so the pages are cut inside
+		// runs of equal keys and only the ID breaks the ties
+		specs := []struct {
+			title, status, priority string
+			start                   int
+		}{
+			{"b", "pending", "high", 1},
+			{"a", "completed", "low", 0},
+			{"b", "pending", "high", 1},
+			{"a", "pending", "urgent", 1},
+			{"b", "completed", "high", 0},
+			{"b", "pending", "low", 1},
+			{"a", "pending", "high", 2},
+			{"b", "pending", "high", 1},
+		}
+		var created []models.Task
+		for _, spec := range specs {
+			task := models.Task{Title: spec.title, Status: spec.status, Priority: spec.priority,
+				StartDate: day.AddDate(0, 0, spec.start), DueDate: day.AddDate(0, 0, 7), UserID: 1, AssignedTo: 1}
+			if err := s.Tasks.CreateTask(&task, nil); err != nil {
+				t.Fatal(err)
+			}
+			created = append(created, task)
+		}
+
+		for _, key := range []string{"id", "title", "status", "priority", "start_date", "due_date"} {
+			for _, desc := range []bool{false, true} {
+				sort := TaskSort{Key: key, Desc: desc}
+				t.Run(sort.String(), func(t *testing.T) {
+					full, err := s.Tasks.ListTasks(TaskQuery{Sort: sort, Limit: MaxTaskLimit})
+					if err != nil {
+						t.Fatal(err)
+					}
+					for i := 1; i < len(full.Tasks); i++ {
+						a, b := full.Tasks[i-1], full.Tasks[i]
+						c := compareTasks(a, b, key)
+						if c == 0 {
+							c = a.ID - b.ID
+						}
+						if (c > 0) != desc || c == 0 {
+							t.Errorf("task %d is listed before task %d", a.ID, b.ID)
+						}
+					}
+					if len(full.Tasks) != len(created) {
+						t.Fatalf("listed %d tasks, want %d", len(full.Tasks), len(created))
+					}
+
+					for _, limit := range []int{1, 2, 3} {
+						got := allPages(t, s, TaskQuery{Sort: sort, Limit: limit})
+						if want := taskIDs(full.Tasks); !reflect.DeepEqual(got, want) {
+							t.Errorf("limit %d: pages %v, want %v", limit, got, want)
+						}
+					}
+				})
+			}
+		}
+	})
+}
+
+func TestListTasksCursorSurvivesInserts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		for i := 0; i < 4; i++ {
+			newTask(t, s, "same", 1, 1, "pending")
+		}
+		q := TaskQuery{Sort: TaskSort{Key: "title"}, Limit: 2}
+		first, err := s.Tasks.ListTasks(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a task with the same title sorts after the cursor by its ID, one
+		// with a smaller title before it
+		late := newTask(t, s, "same", 1, 1, "pending")
+		newTask(t, s, "earlier", 1, 1, "pending")
+
+		q.Cursor = first.NextCursor
+		rest := allPages(t, s, q)
+		got := append(taskIDs(first.Tasks), rest...)
+		if want := []int{1, 2, 3, 4, late.ID}; !reflect.DeepEqual(got, want) {
+			t.Errorf("pages = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestListTasksCursorOfAnotherSort(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		for i := 0; i < 3; i++ {
+			newTask(t, s, "t", 1, 1, "pending")
+		}
+		page, err := s.Tasks.ListTasks(TaskQuery{Sort: TaskSort{Key: "title"}, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sort := range []TaskSort{{Key: "title", Desc: true}, {Key: "status"}, {Key: "id"}} {
+			_, err := s.Tasks.ListTasks(TaskQuery{Sort: sort, Limit: 1, Cursor: page.NextCursor})
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("cursor of title used with %s: %v, want ErrInvalidCursor", sort, err)
+			}
+		}
+	})
+}
+
+func TestParseTaskSort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TaskSort
+		wantErr bool
+	}{
+		{"", TaskSort{Key: "id"}, false},
+		{"title", TaskSort{Key: "title"}, false},
+		{"-due_date", TaskSort{Key: "due_date", Desc: true}, false},
+		{"-priority", TaskSort{Key: "priority", Desc: true}, false},
+		{"owner", TaskSort{}, true},
+		{"--title", TaskSort{}, true},
+		{"id;DROP TABLE tasks", TaskSort{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTaskSort(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTaskSort(%q) = %+v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTaskSort(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeTaskCursor(t *testing.T) {
+	sort := TaskSort{Key: "start_date"}
+	task := models.Task{ID: 7, StartDate: time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)}
+	c, err := decodeTaskCursor(sort, encodeTaskCursor(sort, task))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := cursorTask(c, sort.Key)
+	if err != nil || got.ID != 7 || !got.StartDate.Equal(task.StartDate) {
+		t.Errorf("cursor task = %+v, %v", got, err)
+	}
+	for _, bad := range []string{"", "!!!", "e30", "bm90IGpzb24"} {
+		if _, err := decodeTaskCursor(sort, bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeTaskCursor(%q) = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+}
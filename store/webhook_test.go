@@ -0,0 +1,48 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"task-management-system/models"
+	"testing"
+)
+
+func TestCreateDeliveryOncePerEvent(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s stores) {
+		alice := newUser(t, s, "alice")
+		var hooks []models.Webhook
+		for _, url := range []string{"https://example.com/one", "https://example.com/two"} {
+			hook := models.Webhook{UserID: alice.ID, URL: url, Secret: "secret", Events: []string{models.EventTaskCreated}, Active: true, CreatedAt: day}
+			if err := s.Webhooks.CreateWebhook(&hook); err != nil {
+				t.Fatal(err)
+			}
+			hooks = append(hooks, hook)
+		}
+		tests := []struct {
+			name      string
+			webhookID int
+			eventID   int64
+			want      error
+		}{
+			{"event", hooks[0].ID, 1, nil},
+			{"event again", hooks[0].ID, 1, ErrConflict},
+			{"event for another webhook", hooks[1].ID, 1, nil},
+			{"another event", hooks[0].ID, 2, nil},
+		}
+		for _, tt := range tests {
+			d := models.WebhookDelivery{WebhookID: tt.webhookID, EventID: tt.eventID, EventType: models.EventTaskCreated,
+				Payload: json.RawMessage(`{}`), Status: models.DeliveryPending, NextAttemptAt: &day, CreatedAt: day}
+			if err := s.Webhooks.CreateDelivery(&d); !errors.Is(err, tt.want) {
+				t.Errorf("%s: CreateDelivery = %v, want %v", tt.name, err, tt.want)
+			}
+		}
+
+		deliveries, err := s.Webhooks.ListDeliveries(hooks[0].ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) != 2 {
+			t.Errorf("the webhook has %d deliveries, want 2", len(deliveries))
+		}
+	})
+}
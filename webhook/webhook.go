@@ -0,0 +1,151 @@
+// Package webhook sends signed event payloads to the URLs users subscribed.
+//
+// Every request carries the headers
+//
+//	X-Webhook-Event:     the event type, e.g. task.created
+//	X-Webhook-Delivery:  the delivery ID, the same for every attempt
+//	X-Webhook-Timestamp: the Unix time of the attempt
+//	X-Webhook-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Receivers recompute the signature with their secret and should reject
+// requests with old timestamps.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// reservedPrefixes are the non-public ranges the net.IP methods do not cover.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// IsPublic reports whether ip may receive webhooks: it is not loopback,
+// private, link-local (which includes the 169.254.169.254 metadata
+// endpoint of cloud providers), multicast, unspecified or reserved.
+func IsPublic(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient returns the client deliveries are sent with. It connects only to
+// public addresses, checked on the addresses the host resolves to when the
+// request is made, so a name pointed at an internal address after the
+// webhook was created is refused too. Redirects are not followed, they are
+// reported as failed deliveries. Proxies from the environment are not used.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				if !IsPublic(ip.IP) {
+					return nil, fmt.Errorf("%s resolves to non-public address %s", host, ip.IP)
+				}
+			}
+			// çözülen adrese bağlan, ikinci bir sorgu başka adres döndüremesin
+			var errs []error
+			for _, ip := range ips {
+				conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+				if err == nil {
+					return conn, nil
+				}
+				errs = append(errs, err)
+			}
+			return nil, errors.Join(errs...)
+		},
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConnsPerHost: 2,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Sign returns the value of the X-Webhook-Signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request is one attempt to deliver an event.
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int
+	Body       []byte
+}
+
+// Send posts the request and returns the status code of the response. Any
+// status outside 2xx is reported as an error together with the code.
+func Send(ctx context.Context, client *http.Client, req Request, now time.Time) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "task-management-system-webhook/1")
+	httpReq.Header.Set("X-Webhook-Event", req.Event)
+	httpReq.Header.Set("X-Webhook-Delivery", strconv.Itoa(req.DeliveryID))
+	httpReq.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set("X-Webhook-Signature", Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// bağlantı yeniden kullanılabilsin diye gövdenin bir kısmını oku
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)} {
+		_, err := Send(context.Background(), NewClient(time.Second), Request{URL: url, Secret: "secret", Event: "task.created", Body: []byte("{}")}, time.Now())
+		if err == nil || !strings.Contains(err.Error(), "non-public address") {
+			t.Errorf("Send(%s) error = %v, want a non-public address error", url, err)
+		}
+	}
+	if called {
+		t.Error("the loopback server was called")
+	}
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	client := NewClient(time.Second)
+	// yalnızca yönlendirme davranışı denenir, adres denetimi atlanır
+	client.Transport = http.DefaultTransport
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hook" {
+			http.Redirect(w, r, "/internal", http.StatusFound)
+			return
+		}
+		t.Errorf("redirect to %s was followed", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	status, err := Send(context.Background(), client, Request{URL: srv.URL + "/hook", Secret: "secret", Event: "task.created", Body: []byte("{}")}, time.Now())
+	if status != http.StatusFound || err == nil {
+		t.Errorf("Send = %d, %v, want %d and an error", status, err, http.StatusFound)
+	}
+}
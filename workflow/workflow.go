@@ -0,0 +1,153 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Workflow is the set of task statuses and the transitions allowed between
+// them.
+type Workflow struct {
+	// Initial is the status of a new task when none is given.
+	Initial     string              `yaml:"initial" json:"initial"`
+	Transitions map[string][]string `yaml:"transitions" json:"transitions"`
+	// Terminal statuses end the work on a task, whether it was finished or
+	// not: it no longer blocks other tasks, gets no reminders and lets a
+	// recurring series move on. Done lists the terminal statuses that mean
+	// the task was finished, they count as completed in progress and stats.
+	Terminal []string `yaml:"terminal" json:"terminal"`
+	Done     []string `yaml:"done" json:"done"`
+	// RequiresUnblocked lists the statuses a task cannot move to while one
+	// of its blockers is not terminal.
+	RequiresUnblocked []string `yaml:"requires_unblocked" json:"requires_unblocked"`
+}
+
+func Default() Workflow {
+	return Workflow{
+		Initial: "pending",
+		Transitions: map[string][]string{
+			"pending":     {"in_progress", "blocked", "cancelled"},
+			"in_progress": {"review", "blocked", "pending", "cancelled"},
+			"review":      {"completed", "in_progress"},
+			"blocked":     {"pending", "in_progress", "cancelled"},
+			"completed":   {"in_progress"},
+			"cancelled":   {"pending"},
+		},
+		Terminal:          []string{"completed", "cancelled"},
+		Done:              []string{"completed"},
+		RequiresUnblocked: []string{"in_progress", "completed"},
+	}
+}
+
+// TransitionError is returned for a status change the workflow does not allow.
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("cannot change status from %q to %q, %q is a final status", e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("cannot change status from %q to %q, allowed: %s", e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// Statuses returns every status of the workflow in alphabetical order.
+func (w Workflow) Statuses() []string {
+	var statuses []string
+	for status := range w.Transitions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+func (w Workflow) IsStatus(status string) bool {
+	_, ok := w.Transitions[status]
+	return ok
+}
+
+func (w Workflow) IsTerminal(status string) bool {
+	return contains(w.Terminal, status)
+}
+
+func (w Workflow) IsDone(status string) bool {
+	return contains(w.Done, status)
+}
+
+func (w Workflow) NeedsUnblocked(status string) bool {
+	return contains(w.RequiresUnblocked, status)
+}
+
+// OpenStatuses returns the statuses that are not terminal in alphabetical
+// order.
+func (w Workflow) OpenStatuses() []string {
+	var statuses []string
+	for _, status := range w.Statuses() {
+		if !w.IsTerminal(status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+func contains(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns nil when a task may move from one status to the
+// other. Tasks in a status unknown to the workflow (e.g. created before it
+// was configured) may move to any known status.
+func (w Workflow) CheckTransition(from, to string) error {
+	if !w.IsStatus(to) {
+		return fmt.Errorf("unknown status %q, expected one of: %s", to, strings.Join(w.Statuses(), ", "))
+	}
+	allowed, ok := w.Transitions[from]
+	if !ok {
+		return nil
+	}
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to, Allowed: allowed}
+}
+
+func (w Workflow) Validate() error {
+	if !w.IsStatus(w.Initial) {
+		return fmt.Errorf("workflow.initial %q is not a status of the workflow", w.Initial)
+	}
+	var errs []error
+	if len(w.Terminal) == 0 || len(w.Done) == 0 {
+		errs = append(errs, errors.New("workflow.terminal and workflow.done must list at least one status"))
+	}
+	for name, statuses := range map[string][]string{"terminal": w.Terminal, "done": w.Done, "requires_unblocked": w.RequiresUnblocked} {
+		for _, status := range statuses {
+			if !w.IsStatus(status) {
+				errs = append(errs, fmt.Errorf("workflow.%s: unknown status %q", name, status))
+			}
+		}
+	}
+	for _, status := range w.Done {
+		if !w.IsTerminal(status) {
+			errs = append(errs, fmt.Errorf("workflow.done: %q is not a terminal status", status))
+		}
+	}
+	for from, targets := range w.Transitions {
+		for _, to := range targets {
+			if !w.IsStatus(to) {
+				errs = append(errs, fmt.Errorf("workflow.transitions.%s: unknown status %q", from, to))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,125 @@
+package workflow
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckTransition(t *testing.T) {
+	w := Default()
+	w.Transitions["archived"] = nil
+	tests := []struct {
+		from, to string
+		// wantErr is a part of the error message, empty when the transition
+		// is allowed.
+		wantErr string
+	}{
+		{"pending", "in_progress", ""},
+		{"in_progress", "review", ""},
+		{"review", "completed", ""},
+		{"completed", "in_progress", ""},
+		{"cancelled", "pending", ""},
+		{"pending", "completed", `cannot change status from "pending" to "completed", allowed: in_progress, blocked, cancelled`},
+		{"review", "cancelled", `allowed: completed, in_progress`},
+		{"pending", "pending", `cannot change status from "pending" to "pending"`},
+		{"archived", "pending", `"archived" is a final status`},
+		{"pending", "done", `unknown status "done", expected one of: archived, blocked, cancelled, completed, in_progress, pending, review`},
+		// tasks from before the workflow was configured
+		{"todo", "review", ""},
+		{"todo", "done", `unknown status "done"`},
+	}
+	for _, tt := range tests {
+		err := w.CheckTransition(tt.from, tt.to)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s -> %s: %v", tt.from, tt.to, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s -> %s = %v, want %q", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckTransitionError(t *testing.T) {
+	err := Default().CheckTransition("review", "blocked")
+	var transition *TransitionError
+	if !errors.As(err, &transition) {
+		t.Fatalf("err = %v, want a TransitionError", err)
+	}
+	want := &TransitionError{From: "review", To: "blocked", Allowed: []string{"completed", "in_progress"}}
+	if !reflect.DeepEqual(transition, want) {
+		t.Errorf("err = %+v, want %+v", transition, want)
+	}
+	// an unknown status is not a transition the workflow forbids
+	if err := Default().CheckTransition("review", "done"); errors.As(err, &transition) {
+		t.Errorf("unknown status returned a TransitionError: %v", err)
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	w := Default()
+	if got, want := w.Statuses(), []string{"blocked", "cancelled", "completed", "in_progress", "pending", "review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Statuses = %v, want %v", got, want)
+	}
+	if got, want := w.OpenStatuses(), []string{"blocked", "in_progress", "pending", "review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OpenStatuses = %v, want %v", got, want)
+	}
+	if !w.IsDone("completed") || w.IsDone("cancelled") || !w.IsTerminal("cancelled") || !w.NeedsUnblocked("completed") || w.NeedsUnblocked("review") {
+		t.Error("the default workflow classifies its statuses wrong")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(w *Workflow)
+		// wantErrs are parts of the error message, none when the workflow
+		// is valid.
+		wantErrs []string
+	}{
+		{"default", func(w *Workflow) {}, nil},
+		{"unknown initial", func(w *Workflow) { w.Initial = "new" }, []string{`workflow.initial "new" is not a status of the workflow`}},
+		{"no terminal", func(w *Workflow) { w.Terminal = nil; w.Done = nil }, []string{"workflow.terminal and workflow.done must list at least one status"}},
+		{"no done", func(w *Workflow) { w.Done = nil }, []string{"workflow.terminal and workflow.done must list at least one status"}},
+		{"unknown terminal", func(w *Workflow) { w.Terminal = append(w.Terminal, "archived") }, []string{`workflow.terminal: unknown status "archived"`}},
+		{"unknown requires_unblocked", func(w *Workflow) { w.RequiresUnblocked = []string{"started"} }, []string{`workflow.requires_unblocked: unknown status "started"`}},
+		{"done not terminal", func(w *Workflow) { w.Done = []string{"review"} }, []string{`workflow.done: "review" is not a terminal status`}},
+		{"unknown target", func(w *Workflow) { w.Transitions["review"] = []string{"completed", "approved"} }, []string{`workflow.transitions.review: unknown status "approved"`}},
+		{
+			"every error at once",
+			func(w *Workflow) {
+				w.Done = []string{"review", "shipped"}
+				w.Transitions["blocked"] = []string{"waiting"}
+			},
+			[]string{
+				`workflow.done: unknown status "shipped"`,
+				`workflow.done: "review" is not a terminal status`,
+				`workflow.transitions.blocked: unknown status "waiting"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		w := Default()
+		tt.change(&w)
+		err := w.Validate()
+		if len(tt.wantErrs) == 0 {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: the workflow is valid", tt.name)
+			continue
+		}
+		for _, want := range tt.wantErrs {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: %v, want %q", tt.name, err, want)
+			}
+		}
+	}
+}